@@ -0,0 +1,59 @@
+package indiserver
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// AttachServer uses an indiserver that was started elsewhere, e.g. by systemd, listening on
+// port, instead of starting one. If fifoPath is not empty it must be the FIFO that indiserver
+// was started with (-f), and StartDriver and StopDriver write to it; otherwise they return
+// ErrNotRunning. StopServer detaches again without stopping the indiserver.
+func (s *INDIServer) AttachServer(port, fifoPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.proc != nil || s.attached || s.startDone != nil {
+		return errors.New("indiserver is already running")
+	}
+
+	if len(fifoPath) > 0 {
+		// The server is already running, so its end of the FIFO should be open. Don't block
+		// if it isn't.
+		f, err := s.fs.OpenFile(fifoPath, os.O_WRONLY|syscall.O_NONBLOCK, os.ModeNamedPipe)
+		if err != nil {
+			s.log.WithError(err).Warn("error in s.fs.OpenFile")
+			return err
+		}
+
+		s.fifo = f
+	}
+
+	if len(port) > 0 {
+		s.port = port
+	}
+
+	s.attached = true
+	s.fifoPath = fifoPath
+	s.done = make(chan struct{})
+	s.exitErr = nil
+
+	return nil
+}
+
+// detach forgets an attached indiserver. The caller must hold s.mu.
+func (s *INDIServer) detach() {
+	if s.fifo != nil {
+		err := s.fifo.Close()
+		if err != nil {
+			s.log.WithError(err).Warn("error in s.fifo.Close")
+		}
+
+		s.fifo = nil
+	}
+
+	s.attached = false
+	s.started = nil
+	s.finish(nil)
+}
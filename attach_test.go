@@ -0,0 +1,106 @@
+package indiserver
+
+import (
+	"bufio"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestAttachServer(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder)
+
+	dir := newTestFIFO(t, s)
+	defer os.RemoveAll(dir)
+
+	fifoPath := s.fifoPath
+
+	// Stand in for an indiserver started elsewhere.
+	reader, err := os.OpenFile(fifoPath, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	err = s.AttachServer("7625", fifoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := s.Status()
+	if !st.Running || !st.Attached || st.Port != "7625" {
+		t.Fatalf("unexpected status %+v", st)
+	}
+
+	// Starting the server while attached does nothing.
+	err = s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cmder.count() != 0 {
+		t.Fatal("expected no indiserver to be started")
+	}
+
+	_, err = s.StartDriver("indi_asi_ccd", "CCD 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader.SetReadDeadline(time.Now().Add(time.Second))
+
+	line, err := bufio.NewReader(reader).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if line != "start indi_asi_ccd -n \"CCD 1\"\n" {
+		t.Fatalf("got %q", line)
+	}
+
+	err = s.StopServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The FIFO belongs to the external server, so it is left alone.
+	_, err = os.Stat(fifoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectDone(t, s)
+
+	if s.Status().Running {
+		t.Fatal("expected to be detached")
+	}
+
+	_, err = s.StartDriver("indi_asi_ccd", "CCD 1")
+	if err != ErrNotRunning {
+		t.Fatalf("got %v, expected ErrNotRunning", err)
+	}
+}
+
+func TestAttachServerWithoutFIFO(t *testing.T) {
+	s := newTestServer(t, afero.NewOsFs(), &fakeCommander{})
+
+	err := s.AttachServer("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	_, err = s.StartDriver("indi_asi_ccd", "CCD 1")
+	if err != ErrNotRunning {
+		t.Fatalf("got %v, expected ErrNotRunning", err)
+	}
+
+	err = s.AttachServer("", "")
+	if err == nil {
+		t.Fatal("expected an error attaching twice")
+	}
+}
@@ -0,0 +1,121 @@
+package indiserver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrDriverFailed is returned when indiserver reports that a driver failed while its start was
+// being confirmed. Returned errors wrap it and include the line indiserver printed.
+var ErrDriverFailed = errors.New("driver failed to start")
+
+// driverFailures are printed by indiserver after "Driver <driver>: " when a driver couldn't be
+// run or exited.
+var driverFailures = []string{
+	"execlp",
+	"No such file",
+	"stderr EOF",
+	"stdin EOF",
+	"restart #",
+	"Terminated after",
+}
+
+// ConfirmDriverStart makes StartDriver, StartDriverSpec and StartDrivers watch indiserver's
+// output for window after starting drivers, and fail with ErrDriverFailed if indiserver reports
+// that a driver couldn't be run or exited. A driver that is still up when the window ends is
+// considered started. indiserver only names the driver binary, so a failure of one device is
+// reported for every device started from the same driver at the same time. Failed drivers are
+// no longer tracked as running.
+func ConfirmDriverStart(window time.Duration) Option {
+	return func(s *INDIServer) {
+		s.confirmWindow = window
+	}
+}
+
+// handleOutput logs a line from indiserver and passes it on to any watchers.
+func (s *INDIServer) handleOutput(line string) {
+	s.log.WithField("line", line).Info("from indiserver")
+
+	s.outputMu.Lock()
+	defer s.outputMu.Unlock()
+
+	for ch := range s.outputWatchers {
+		select {
+		case ch <- line:
+		default:
+			// Don't block indiserver's output on a slow watcher.
+		}
+	}
+}
+
+// watchOutput returns a channel receiving indiserver's output lines until stop is called.
+func (s *INDIServer) watchOutput() (lines <-chan string, stop func()) {
+	ch := make(chan string, 100)
+
+	s.outputMu.Lock()
+	if s.outputWatchers == nil {
+		s.outputWatchers = map[chan string]bool{}
+	}
+	s.outputWatchers[ch] = true
+	s.outputMu.Unlock()
+
+	return ch, func() {
+		s.outputMu.Lock()
+		delete(s.outputWatchers, ch)
+		s.outputMu.Unlock()
+	}
+}
+
+// confirmStart watches lines for window and returns an error for every spec whose driver
+// indiserver reported as failed, or nil for those that stayed up.
+func (s *INDIServer) confirmStart(specs []DriverSpec, lines <-chan string, window time.Duration) []error {
+	errs := make([]error, len(specs))
+
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			return errs
+		case line := <-lines:
+			for i, spec := range specs {
+				if errs[i] == nil && driverFailed(line, spec.Driver) {
+					s.log.WithField("driver", spec.Driver).WithField("line", line).Warn("driver failed to start")
+					errs[i] = fmt.Errorf("%w: %s", ErrDriverFailed, line)
+				}
+			}
+		}
+	}
+}
+
+func driverFailed(line, driver string) bool {
+	prefix := "Driver " + driver + ": "
+
+	i := strings.Index(line, prefix)
+	if i < 0 {
+		return false
+	}
+
+	msg := line[i+len(prefix):]
+
+	for _, failure := range driverFailures {
+		if strings.Contains(msg, failure) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// untrack stops tracking the most recent start of spec. The caller must hold s.mu.
+func (s *INDIServer) untrack(spec DriverSpec) {
+	for i := len(s.started) - 1; i >= 0; i-- {
+		if s.started[i].DriverSpec == spec {
+			s.started = append(s.started[:i], s.started[i+1:]...)
+			return
+		}
+	}
+}
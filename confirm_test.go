@@ -0,0 +1,89 @@
+package indiserver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestConfirmDriverStart(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, StopTimeout(0), ConfirmDriverStart(100*time.Millisecond))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	cmd := cmder.last()
+
+	// Report the failure only once the driver has been started.
+	go func() {
+		<-cmd.lines
+		cmd.stderr <- "2020-01-01T00:00:00: Driver indi_asi_ccd: pid=1234 rfd=3 wfd=6 efd=7"
+		cmd.stderr <- "2020-01-01T00:00:00: Driver indi_bogus: execlp indi_bogus: No such file or directory"
+		cmd.stderr <- "2020-01-01T00:00:00: Driver indi_bogus: stderr EOF"
+	}()
+
+	_, err = s.StartDriver("indi_bogus", "")
+	if !errors.Is(err, ErrDriverFailed) {
+		t.Fatalf("expected ErrDriverFailed, got %v", err)
+	}
+
+	if len(s.RunningDrivers()) != 0 {
+		t.Fatalf("expected the failed driver not to be tracked, got %+v", s.RunningDrivers())
+	}
+
+	_, err = s.StartDriver("indi_asi_ccd", "ZWO CCD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmd, `start indi_asi_ccd -n "ZWO CCD"`)
+
+	go func() {
+		<-cmd.lines
+		<-cmd.lines
+		cmd.stderr <- "2020-01-01T00:00:00: Driver indi_moonlite_focus: Terminated after #0 restarts."
+	}()
+
+	result, err := s.StartDrivers([]DriverSpec{{Driver: "indi_eqmod_telescope"}, {Driver: "indi_moonlite_focus"}})
+
+	var errs DriverErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Spec.Driver != "indi_moonlite_focus" {
+		t.Fatalf("expected the focuser to fail, got %v", err)
+	}
+
+	if result[0].Status != StepSucceeded || result[1].Status != StepFailed || result[1].Duration < 100*time.Millisecond {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	running := s.RunningDrivers()
+	if len(running) != 2 || running[0].Driver != "indi_asi_ccd" || running[1].Driver != "indi_eqmod_telescope" {
+		t.Fatalf("unexpected drivers %+v", running)
+	}
+}
+
+func TestDriverFailed(t *testing.T) {
+	tests := []struct {
+		line     string
+		driver   string
+		expected bool
+	}{
+		{"2020-01-01T00:00:00: Driver indi_asi_ccd: execlp indi_asi_ccd: No such file or directory", "indi_asi_ccd", true},
+		{"2020-01-01T00:00:00: Driver indi_asi_ccd: restart #1", "indi_asi_ccd", true},
+		{"2020-01-01T00:00:00: Driver indi_asi_ccd: Terminated after #3 restarts.", "indi_asi_ccd", true},
+		{"2020-01-01T00:00:00: Driver indi_asi_ccd: pid=1234 rfd=3 wfd=6 efd=7", "indi_asi_ccd", false},
+		{"2020-01-01T00:00:00: Driver indi_asi_ccd: stderr EOF", "indi_asi", false},
+		{"2020-01-01T00:00:00: Driver Telescope@pier:7625: stdin EOF", "Telescope@pier:7625", true},
+	}
+
+	for _, test := range tests {
+		if driverFailed(test.line, test.driver) != test.expected {
+			t.Errorf("%q for %s: expected %v", test.line, test.driver, test.expected)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package indiserver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func expectDone(t *testing.T, s *INDIServer) {
+	t.Helper()
+
+	select {
+	case <-s.Done():
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for Done")
+	}
+}
+
+func TestDoneOnExit(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder)
+
+	expectDone(t, s)
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-s.Done():
+		t.Fatal("expected Done to stay open while running")
+	default:
+	}
+
+	exitErr := errors.New("signal: segmentation fault")
+	cmder.last().exit(exitErr)
+
+	expectDone(t, s)
+
+	if s.ExitError() != exitErr {
+		t.Fatalf("got %v, expected %v", s.ExitError(), exitErr)
+	}
+
+	s.StopServer()
+}
+
+func TestDoneOnStop(t *testing.T) {
+	s := newTestServer(t, afero.NewOsFs(), &fakeCommander{})
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.StopServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectDone(t, s)
+
+	if s.ExitError() != nil {
+		t.Fatalf("got %v, expected no error", s.ExitError())
+	}
+}
+
+func TestDoneWhenSupervisionGivesUp(t *testing.T) {
+	policy := testRetryPolicy
+	policy.MaxRetries = 1
+
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, Supervise(policy))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	cmder.set(func(c *fakeCommander) { c.startErr = errors.New("no such file") })
+	cmder.last().exit(errors.New("exit status 1"))
+
+	expectDone(t, s)
+
+	if s.ExitError() == nil || s.ExitError().Error() != "exit status 1" {
+		t.Fatalf("got %v, expected the exit error", s.ExitError())
+	}
+}
+
+func TestStartServerAfterExit(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder)
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.StartDriver("indi_asi_ccd", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := s.fifoPath
+
+	cmder.last().exit(errors.New("signal: segmentation fault"))
+
+	expectDone(t, s)
+	expectRemoved(t, first)
+
+	_, err = s.StartDriver("indi_asi_ccd", "")
+	if err != ErrNotRunning {
+		t.Fatalf("got %v, expected ErrNotRunning", err)
+	}
+
+	if len(s.RunningDrivers()) != 0 {
+		t.Fatalf("expected no running drivers, got %+v", s.RunningDrivers())
+	}
+
+	err = s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	if cmder.count() != 2 || !s.Status().Running {
+		t.Fatalf("expected a new server, got %d commands", cmder.count())
+	}
+
+	select {
+	case <-s.Done():
+		t.Fatal("expected the new run to have a new Done channel")
+	default:
+	}
+
+	_, err = s.StartDriver("indi_asi_ccd", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmder.last(), "start indi_asi_ccd")
+}
@@ -0,0 +1,44 @@
+package indiserver
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rickbassham/goexec"
+	"github.com/spf13/afero"
+)
+
+func TestDrivers(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/usr/share/indi/drivers.xml", []byte(`<driversList>
+<devGroup group="Telescopes">
+<device label="EQMod Mount"><driver>indi_eqmod_telescope</driver><version>1.0</version></device>
+<device label="Starlight Xpress" skel="indi_sx_sk.xml"><driver>indi_sx_ccd</driver><version>1.3</version></device>
+<device label="Custom" skel="/opt/indi/custom_sk.xml"><driver>indi_custom</driver><version>0.1</version></device>
+</devGroup>
+</driversList>`), 0644)
+
+	s := newTestServer(t, fs, goexec.ExecCommand{})
+
+	expected := []Driver{
+		{Label: "EQMod Mount", Driver: "indi_eqmod_telescope", Version: "1.0"},
+		{Label: "Starlight Xpress", Driver: "indi_sx_ccd", Version: "1.3", Skeleton: "/usr/share/indi/indi_sx_sk.xml"},
+		{Label: "Custom", Driver: "indi_custom", Version: "0.1", Skeleton: "/opt/indi/custom_sk.xml"},
+	}
+
+	actual := s.Drivers()["Telescopes"]
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("got %+v, expected %+v", actual, expected)
+	}
+
+	spec := actual[1].Spec()
+
+	line, err := spec.startCommand().encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if line != "start indi_sx_ccd -n \"Starlight Xpress\" -s \"/usr/share/indi/indi_sx_sk.xml\"\n" {
+		t.Fatalf("got %q", line)
+	}
+}
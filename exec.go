@@ -0,0 +1,119 @@
+package indiserver
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/rickbassham/goexec"
+)
+
+// ExecCommander is a Commander that runs commands with os/exec, like goexec.ExecCommand.
+// Its commands also report their process ID, which lets Status include it, and accept the
+// process attributes and environment needed by options like Credential, ProcessGroup and Env.
+type ExecCommander struct{}
+
+// Command creates a command that runs name with args.
+func (ExecCommander) Command(name string, args ...string) goexec.Command {
+	return &execCommand{
+		cmd: exec.Command(name, args...),
+	}
+}
+
+// pidCommand is implemented by commands that can report their process ID once started.
+type pidCommand interface {
+	Pid() int
+}
+
+// groupCommand is implemented by commands that can signal their whole process group once
+// started in a new one, even after the process itself has exited.
+type groupCommand interface {
+	SignalGroup(sig syscall.Signal) error
+}
+
+type execCommand struct {
+	cmd *exec.Cmd
+}
+
+func (c *execCommand) Start() error {
+	return c.cmd.Start()
+}
+
+func (c *execCommand) Wait() error {
+	return c.cmd.Wait()
+}
+
+func (c *execCommand) Kill() error {
+	return c.cmd.Process.Kill()
+}
+
+func (c *execCommand) SignalGroup(sig syscall.Signal) error {
+	// The process group ID is the pid of its leader.
+	return syscall.Kill(-c.cmd.Process.Pid, sig)
+}
+
+func (c *execCommand) Signal(sig os.Signal) error {
+	return c.cmd.Process.Signal(sig)
+}
+
+func (c *execCommand) Pid() int {
+	if c.cmd.Process == nil {
+		return 0
+	}
+
+	return c.cmd.Process.Pid
+}
+
+func (c *execCommand) SetSysProcAttr(attr *syscall.SysProcAttr) {
+	c.cmd.SysProcAttr = attr
+}
+
+func (c *execCommand) SetEnv(env []string) {
+	c.cmd.Env = env
+}
+
+func (c *execCommand) Stdout() (<-chan string, error) {
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	return readLines(stdout), nil
+}
+
+func (c *execCommand) Stderr() (<-chan string, error) {
+	stderr, err := c.cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	return readLines(stderr), nil
+}
+
+// readLines sends every line read from r on the returned channel, which is closed once r
+// is exhausted.
+func readLines(r io.Reader) <-chan string {
+	lines := make(chan string, 10)
+
+	go func() {
+		defer close(lines)
+
+		br := bufio.NewReader(r)
+
+		for {
+			line, err := br.ReadString('\n')
+			if len(line) > 0 {
+				lines <- strings.TrimSpace(line)
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return lines
+}
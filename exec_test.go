@@ -0,0 +1,77 @@
+package indiserver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExecCommander(t *testing.T) {
+	cmd := ExecCommander{}.Command("/bin/sh", "-c", "echo out; echo err >&2; printf last")
+
+	stdout, err := cmd.Stdout()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr, err := cmd.Stderr()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cmd.(pidCommand).Pid() == 0 {
+		t.Fatal("expected a pid")
+	}
+
+	out := []string{}
+	for line := range stdout {
+		out = append(out, line)
+	}
+
+	errOut := []string{}
+	for line := range stderr {
+		errOut = append(errOut, line)
+	}
+
+	err = cmd.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(out, []string{"out", "last"}) || !reflect.DeepEqual(errOut, []string{"err"}) {
+		t.Fatalf("got %q and %q", out, errOut)
+	}
+}
+
+func TestExecCommanderEnv(t *testing.T) {
+	cmd := ExecCommander{}.Command("/bin/sh", "-c", "echo $INDI_TEST_VAR")
+	cmd.(envCommand).SetEnv([]string{"INDI_TEST_VAR=first", "INDI_TEST_VAR=second"})
+
+	stdout, err := cmd.Stdout()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := []string{}
+	for line := range stdout {
+		out = append(out, line)
+	}
+
+	err = cmd.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(out, []string{"second"}) {
+		t.Fatalf("got %q", out)
+	}
+}
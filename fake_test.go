@@ -0,0 +1,288 @@
+package indiserver
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/rickbassham/goexec"
+)
+
+// fakeCommander creates fakeCommands that act like indiserver: they read commands from the
+// FIFO passed with -f until they exit.
+type fakeCommander struct {
+	mu   sync.Mutex
+	cmds []*fakeCommand
+
+	// ignoreFIFO makes new commands never open the FIFO, like a hung indiserver.
+	ignoreFIFO bool
+
+	// ignoreKill makes new commands ignore Kill and Signal. They only exit through exit.
+	ignoreKill bool
+
+	// ignoreSignal makes new commands ignore Signal, but not Kill.
+	ignoreSignal bool
+
+	// orphans makes new commands leave processes behind in their process group when they
+	// exit, until the group is killed.
+	orphans bool
+
+	// startErr is returned by Start of new commands.
+	startErr error
+}
+
+func (c *fakeCommander) set(f func(c *fakeCommander)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f(c)
+}
+
+func (c *fakeCommander) Command(name string, args ...string) goexec.Command {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cmd := &fakeCommand{
+		name:         name,
+		args:         args,
+		ignoreFIFO:   c.ignoreFIFO,
+		ignoreKill:   c.ignoreKill,
+		ignoreSignal: c.ignoreSignal,
+		orphans:      c.orphans,
+		startErr:     c.startErr,
+		stdout:       make(chan string, 10),
+		stderr:       make(chan string, 10),
+		lines:        make(chan string, 100),
+		exitCh:       make(chan error, 1),
+		exited:       make(chan struct{}),
+	}
+
+	c.cmds = append(c.cmds, cmd)
+
+	return cmd
+}
+
+func (c *fakeCommander) last() *fakeCommand {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.cmds) == 0 {
+		return nil
+	}
+
+	return c.cmds[len(c.cmds)-1]
+}
+
+func (c *fakeCommander) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.cmds)
+}
+
+type fakeCommand struct {
+	name         string
+	args         []string
+	ignoreFIFO   bool
+	ignoreKill   bool
+	ignoreSignal bool
+	orphans      bool
+	startErr     error
+
+	stdout chan string
+	stderr chan string
+	lines  chan string   // lines read from the FIFO
+	exitCh chan error    // receives the exit status that ends the process
+	exited chan struct{} // closed once the process has exited
+
+	mu           sync.Mutex
+	fifo         *os.File
+	waitErr      error
+	signals      []os.Signal
+	groupSignals []syscall.Signal
+	groupKilled  bool
+
+	sysProcAttr *syscall.SysProcAttr
+	env         []string
+}
+
+func (c *fakeCommand) arg(flag string) string {
+	for i, a := range c.args {
+		if a == flag && i+1 < len(c.args) {
+			return c.args[i+1]
+		}
+	}
+
+	return ""
+}
+
+func (c *fakeCommand) Start() error {
+	if c.startErr != nil {
+		close(c.stdout)
+		close(c.stderr)
+		return c.startErr
+	}
+
+	if !c.ignoreFIFO {
+		go c.readFIFO()
+	}
+
+	go func() {
+		err := <-c.exitCh
+
+		c.mu.Lock()
+		c.waitErr = err
+		if c.fifo != nil {
+			c.fifo.Close()
+		}
+		c.mu.Unlock()
+
+		// Like a real process, exiting closes the output pipes.
+		close(c.stdout)
+		close(c.stderr)
+		close(c.exited)
+	}()
+
+	return nil
+}
+
+func (c *fakeCommand) readFIFO() {
+	f, err := os.OpenFile(c.arg("-f"), os.O_RDONLY, 0)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	select {
+	case <-c.exited:
+		c.mu.Unlock()
+		f.Close()
+		return
+	default:
+		c.fifo = f
+	}
+	c.mu.Unlock()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		c.lines <- scanner.Text()
+	}
+}
+
+// exit makes the fake process exit with err, unless it is already exiting.
+func (c *fakeCommand) exit(err error) {
+	select {
+	case c.exitCh <- err:
+	default:
+	}
+}
+
+func (c *fakeCommand) Wait() error {
+	<-c.exited
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.waitErr
+}
+
+func (c *fakeCommand) Kill() error {
+	if !c.ignoreKill {
+		c.exit(errors.New("signal: killed"))
+	}
+
+	return nil
+}
+
+func (c *fakeCommand) Signal(sig os.Signal) error {
+	c.mu.Lock()
+	c.signals = append(c.signals, sig)
+	c.mu.Unlock()
+
+	if !c.ignoreKill && !c.ignoreSignal {
+		c.exit(errors.New("signal: " + sig.String()))
+	}
+
+	return nil
+}
+
+func (c *fakeCommand) receivedSignals() []os.Signal {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]os.Signal{}, c.signals...)
+}
+
+func (c *fakeCommand) Stdout() (<-chan string, error) {
+	return c.stdout, nil
+}
+
+func (c *fakeCommand) Stderr() (<-chan string, error) {
+	return c.stderr, nil
+}
+
+func (c *fakeCommand) Pid() int {
+	return 1234
+}
+
+func (c *fakeCommand) SetSysProcAttr(attr *syscall.SysProcAttr) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sysProcAttr = attr
+}
+
+// SignalGroup signals the fake's process group: the process itself and, with orphans, the
+// drivers it leaves behind until the group is killed.
+func (c *fakeCommand) SignalGroup(sig syscall.Signal) error {
+	c.mu.Lock()
+	if sig == 0 {
+		defer c.mu.Unlock()
+
+		select {
+		case <-c.exited:
+			if !c.orphans || c.groupKilled {
+				return syscall.ESRCH
+			}
+		default:
+		}
+
+		return nil
+	}
+
+	c.groupSignals = append(c.groupSignals, sig)
+	if sig == syscall.SIGKILL {
+		c.groupKilled = true
+	}
+	c.mu.Unlock()
+
+	if sig == syscall.SIGKILL {
+		return c.Kill()
+	}
+
+	return c.Signal(sig)
+}
+
+func (c *fakeCommand) receivedGroupSignals() []syscall.Signal {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]syscall.Signal{}, c.groupSignals...)
+}
+
+func (c *fakeCommand) SetEnv(env []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.env = env
+}
+
+// goexecCommander creates plain goexec commands, which support none of the optional command
+// interfaces.
+type goexecCommander struct{}
+
+func (goexecCommander) Command(name string, args ...string) goexec.Command {
+	return goexec.NewCommand(name, args...)
+}
@@ -0,0 +1,128 @@
+package indiserver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidDriverName is returned when a driver, device name or other driver argument can't
+// be written to the indiserver FIFO without corrupting the command stream, e.g. because it
+// contains a double quote or a newline. Returned errors wrap it; check with errors.Is.
+var ErrInvalidDriverName = errors.New("invalid driver name")
+
+// maxFIFOValue is the longest driver or quoted value indiserver reads from a FIFO command.
+const maxFIFOValue = 511
+
+// fifoCommand builds a single line of the indiserver FIFO protocol:
+//
+//	start <driver> [-n "<name>"] [-c "<config>"] [-s "<skeleton>"] [-p "<prefix>"]
+//	stop <driver> [-n "<name>"]
+//
+// indiserver reads the driver up to the first whitespace and each quoted value up to the
+// next double quote, so neither may contain characters that would end them early.
+type fifoCommand struct {
+	verb     string
+	driver   string
+	name     string
+	config   string
+	skeleton string
+	prefix   string
+}
+
+func startCommand(driver string) *fifoCommand {
+	return &fifoCommand{verb: "start", driver: driver}
+}
+
+func stopCommand(driver string) *fifoCommand {
+	return &fifoCommand{verb: "stop", driver: driver}
+}
+
+// withName sets the device name (-n). For stop commands it limits the stop to that device.
+func (c *fifoCommand) withName(name string) *fifoCommand {
+	c.name = name
+	return c
+}
+
+// withConfig sets the driver config file (-c). Only used by start commands.
+func (c *fifoCommand) withConfig(config string) *fifoCommand {
+	c.config = config
+	return c
+}
+
+// withSkeleton sets the driver skeleton file (-s). Only used by start commands.
+func (c *fifoCommand) withSkeleton(skeleton string) *fifoCommand {
+	c.skeleton = skeleton
+	return c
+}
+
+// withPrefix sets the driver's INDIPREFIX (-p). Only used by start commands.
+func (c *fifoCommand) withPrefix(prefix string) *fifoCommand {
+	c.prefix = prefix
+	return c
+}
+
+// remoteSpec returns the driver for a device on another indiserver: device@host[:port].
+func remoteSpec(device, host, port string) (string, error) {
+	if len(device) == 0 || len(host) == 0 {
+		return "", fmt.Errorf("%w: remote driver needs a device and a host", ErrInvalidDriverName)
+	}
+
+	if strings.Contains(device, "@") || strings.ContainsAny(host, "@:") || strings.Contains(port, ":") {
+		return "", fmt.Errorf("%w: remote driver %s@%s:%s", ErrInvalidDriverName, device, host, port)
+	}
+
+	if len(port) == 0 {
+		return device + "@" + host, nil
+	}
+
+	return device + "@" + host + ":" + port, nil
+}
+
+// encode returns the FIFO line for the command, or an error if any part of it cannot be
+// represented in the FIFO grammar.
+func (c *fifoCommand) encode() (string, error) {
+	if len(c.driver) == 0 {
+		return "", fmt.Errorf("%w: empty driver", ErrInvalidDriverName)
+	}
+
+	if len(c.driver) > maxFIFOValue || strings.ContainsAny(c.driver, " \t\r\n\v\f\"\x00") {
+		return "", fmt.Errorf("%w: driver %q", ErrInvalidDriverName, c.driver)
+	}
+
+	var b strings.Builder
+
+	b.WriteString(c.verb)
+	b.WriteString(" ")
+	b.WriteString(c.driver)
+
+	writeArg := func(flag, value string) error {
+		if len(value) == 0 {
+			return nil
+		}
+
+		if len(value) > maxFIFOValue || strings.ContainsAny(value, "\r\n\"\x00") {
+			return fmt.Errorf("%w: value %q for -%s", ErrInvalidDriverName, value, flag)
+		}
+
+		fmt.Fprintf(&b, " -%s \"%s\"", flag, value)
+
+		return nil
+	}
+
+	args := [][2]string{{"n", c.name}}
+	if c.verb == "start" {
+		args = append(args, [2]string{"c", c.config}, [2]string{"s", c.skeleton}, [2]string{"p", c.prefix})
+	}
+
+	for _, arg := range args {
+		err := writeArg(arg[0], arg[1])
+		if err != nil {
+			return "", err
+		}
+	}
+
+	b.WriteString("\n")
+
+	return b.String(), nil
+}
@@ -0,0 +1,114 @@
+//go:build go1.18
+// +build go1.18
+
+package indiserver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// parseFIFOLine reads a FIFO line the way indiserver does: the verb and driver up to
+// whitespace, then each flag followed by a value up to the next double quote.
+func parseFIFOLine(t *testing.T, line string) *fifoCommand {
+	t.Helper()
+
+	if !strings.HasSuffix(line, "\n") || strings.Count(line, "\n") != 1 {
+		t.Fatalf("expected a single line, got %q", line)
+	}
+
+	fields := strings.SplitN(strings.TrimSuffix(line, "\n"), " ", 3)
+	if len(fields) < 2 {
+		t.Fatalf("expected a verb and a driver, got %q", line)
+	}
+
+	// C strings end at a NUL, and indiserver reads the driver with %s, which stops at any
+	// whitespace.
+	if strings.ContainsAny(line, "\x00") || strings.ContainsAny(fields[1], "\t\r\v\f") {
+		t.Fatalf("driver or value would be cut short in %q", line)
+	}
+
+	cmd := &fifoCommand{verb: fields[0], driver: fields[1]}
+	if len(fields) == 2 {
+		return cmd
+	}
+
+	rest := fields[2]
+	for len(rest) > 0 {
+		if len(rest) < 5 || rest[0] != '-' || rest[2:4] != " \"" {
+			t.Fatalf("malformed argument %q in %q", rest, line)
+		}
+
+		end := strings.IndexByte(rest[4:], '"')
+		if end < 0 {
+			t.Fatalf("unterminated value in %q", line)
+		}
+
+		value := rest[4 : 4+end]
+
+		switch rest[1] {
+		case 'n':
+			cmd.name = value
+		case 'c':
+			cmd.config = value
+		case 's':
+			cmd.skeleton = value
+		case 'p':
+			cmd.prefix = value
+		default:
+			t.Fatalf("unknown flag %q in %q", rest[:2], line)
+		}
+
+		rest = strings.TrimPrefix(rest[4+end+1:], " ")
+	}
+
+	return cmd
+}
+
+func FuzzFIFOCommand(f *testing.F) {
+	f.Add("indi_asi_ccd", "CCD 1", "", "", "")
+	f.Add("indi_eqmod_telescope", "EQMod Mount", "/etc/eqmod.xml", "/usr/share/indi/skel.xml", "EQ")
+	f.Add("Telescope@pier:7625", "", "", "", "")
+	f.Add("indi_asi_ccd", "CCD \"1\"\nstop indi_eqmod_telescope", "", "", "")
+	f.Add("indi asi", "-n", "\r", "\"", "")
+
+	f.Fuzz(func(t *testing.T, driver, name, config, skeleton, prefix string) {
+		cmd := startCommand(driver).withName(name).withConfig(config).withSkeleton(skeleton).withPrefix(prefix)
+
+		line, err := cmd.encode()
+		if err != nil {
+			if !errors.Is(err, ErrInvalidDriverName) {
+				t.Fatalf("expected ErrInvalidDriverName, got %v", err)
+			}
+			return
+		}
+
+		parsed := parseFIFOLine(t, line)
+		if *parsed != *cmd {
+			t.Fatalf("%q parsed as %+v, expected %+v", line, parsed, cmd)
+		}
+	})
+}
+
+func FuzzStopCommand(f *testing.F) {
+	f.Add("indi_asi_ccd", "CCD 1")
+	f.Add("indi_asi_ccd", "")
+
+	f.Fuzz(func(t *testing.T, driver, name string) {
+		cmd := stopCommand(driver).withName(name)
+
+		line, err := cmd.encode()
+		if err != nil {
+			if !errors.Is(err, ErrInvalidDriverName) {
+				t.Fatalf("expected ErrInvalidDriverName, got %v", err)
+			}
+			return
+		}
+
+		parsed := parseFIFOLine(t, line)
+		if *parsed != *cmd {
+			t.Fatalf("%q parsed as %+v, expected %+v", line, parsed, cmd)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package indiserver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFIFOCommand(t *testing.T) {
+	tests := []struct {
+		cmd      *fifoCommand
+		expected string
+	}{
+		{startCommand("indi_asi_ccd"), "start indi_asi_ccd\n"},
+		{startCommand("indi_asi_ccd").withName("CCD 1"), "start indi_asi_ccd -n \"CCD 1\"\n"},
+		{startCommand("indi_asi_ccd").withName("CCD 'one'"), "start indi_asi_ccd -n \"CCD 'one'\"\n"},
+		{startCommand("indi_asi_ccd").withName(""), "start indi_asi_ccd\n"},
+		{stopCommand("indi_asi_ccd"), "stop indi_asi_ccd\n"},
+		{stopCommand("indi_asi_ccd").withName("CCD 1"), "stop indi_asi_ccd -n \"CCD 1\"\n"},
+	}
+
+	for _, test := range tests {
+		actual, err := test.cmd.encode()
+		if err != nil {
+			t.Errorf("%+v: %s", test.cmd, err)
+			continue
+		}
+
+		if actual != test.expected {
+			t.Errorf("got %q, expected %q", actual, test.expected)
+		}
+	}
+}
+
+func TestFIFOCommandInvalid(t *testing.T) {
+	tests := []*fifoCommand{
+		startCommand(""),
+		startCommand("indi asi"),
+		startCommand("indi_asi\n"),
+		startCommand("indi_asi\v"),
+		startCommand("indi_asi\x00"),
+		startCommand("indi_\"asi"),
+		startCommand(strings.Repeat("x", maxFIFOValue+1)),
+		startCommand("indi_asi_ccd").withName(`CCD "1"`),
+		startCommand("indi_asi_ccd").withName("CCD 1\nstop indi_eqmod_telescope"),
+		startCommand("indi_asi_ccd").withName("CCD 1\r"),
+		startCommand("indi_asi_ccd").withName("CCD\x001"),
+		startCommand("indi_asi_ccd").withConfig(`/etc/"eqmod".xml`),
+		startCommand("indi_asi_ccd").withName(strings.Repeat("x", maxFIFOValue+1)),
+		stopCommand("indi_asi_ccd").withName(`CCD "1"`),
+	}
+
+	for _, cmd := range tests {
+		line, err := cmd.encode()
+		if !errors.Is(err, ErrInvalidDriverName) {
+			t.Errorf("%+v: expected ErrInvalidDriverName, got %q, %v", cmd, line, err)
+		}
+	}
+}
+
+func TestRemoteSpec(t *testing.T) {
+	tests := []struct {
+		device, host, port string
+		expected           string
+	}{
+		{"Telescope", "pier", "", "Telescope@pier"},
+		{"Telescope", "pier", "7625", "Telescope@pier:7625"},
+		{"Telescope", "192.168.1.20", "7624", "Telescope@192.168.1.20:7624"},
+		{"", "pier", "", ""},
+		{"Telescope", "", "", ""},
+		{"Tele@scope", "pier", "", ""},
+		{"Telescope", "pier:7625", "", ""},
+	}
+
+	for _, test := range tests {
+		actual, err := remoteSpec(test.device, test.host, test.port)
+		if len(test.expected) == 0 {
+			if !errors.Is(err, ErrInvalidDriverName) {
+				t.Errorf("%+v: expected error, got %q", test, actual)
+			}
+			continue
+		}
+
+		if err != nil || actual != test.expected {
+			t.Errorf("%+v: got %q, %v", test, actual, err)
+		}
+	}
+
+	_, err := startCommand("Main Telescope@pier").encode()
+	if err == nil {
+		t.Error("expected error for a device name indiserver can't read")
+	}
+}
@@ -0,0 +1,45 @@
+package indiserver
+
+// DriverHandle refers to a driver started with StartDriver, so it can be managed without
+// passing the driver and device name around.
+type DriverHandle struct {
+	s    *INDIServer
+	spec DriverSpec
+}
+
+// Name returns the device name the driver was started with, which may be empty.
+func (h *DriverHandle) Name() string {
+	return h.spec.Name
+}
+
+// Driver returns the driver binary.
+func (h *DriverHandle) Driver() string {
+	return h.spec.Driver
+}
+
+// Stop stops the driver like StopDriver. Without a name, every device started from the driver
+// is stopped.
+func (h *DriverHandle) Stop() error {
+	return h.s.StopDriver(h.spec.Driver, h.spec.Name)
+}
+
+// Restart restarts the driver like RestartDriver.
+func (h *DriverHandle) Restart() error {
+	return h.s.RestartDriver(h.spec.Driver, h.spec.Name)
+}
+
+// Running reports whether the driver is still tracked as running: it hasn't been stopped, and
+// the server hasn't been stopped since it was started. Like RunningDrivers, it can't tell
+// whether indiserver failed to start the driver.
+func (h *DriverHandle) Running() bool {
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+
+	for _, d := range h.s.started {
+		if d.DriverSpec == h.spec {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,63 @@
+package indiserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestDriverHandle(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, StopTimeout(0), DriverTimeout(300*time.Millisecond))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	l := listenINDI(t, s.port, "ZWO CCD")
+	defer l.Close()
+
+	cmd := cmder.last()
+
+	ccd, err := s.StartDriver("indi_asi_ccd", "ZWO CCD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmd, `start indi_asi_ccd -n "ZWO CCD"`)
+
+	if ccd.Name() != "ZWO CCD" || ccd.Driver() != "indi_asi_ccd" || !ccd.Running() {
+		t.Fatalf("unexpected handle %+v", ccd)
+	}
+
+	err = ccd.Restart()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmd, `stop indi_asi_ccd -n "ZWO CCD"`)
+	expectLine(t, cmd, `start indi_asi_ccd -n "ZWO CCD"`)
+
+	if !ccd.Running() {
+		t.Fatal("expected the driver to be running after a restart")
+	}
+
+	err = ccd.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmd, `stop indi_asi_ccd -n "ZWO CCD"`)
+
+	if ccd.Running() {
+		t.Fatal("expected the driver to be stopped")
+	}
+
+	_, err = s.StartDriver("indi_asi_ccd", "CCD \"1\"")
+	if err == nil {
+		t.Fatal("expected an error for an invalid name")
+	}
+}
@@ -0,0 +1,267 @@
+package indiserver
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rickbassham/logging"
+	"github.com/spf13/afero"
+)
+
+func newTestServer(t *testing.T, fs afero.Fs, cmder Commander, opts ...Option) *INDIServer {
+	t.Helper()
+
+	logger := logging.NewLogger(ioutil.Discard, logging.JSONFormatter{}, logging.LogLevelInfo)
+
+	// Use a free port, so tests don't fail on machines that run indiserver on the default one.
+	return NewINDIServer(logger, fs, freePort(t), cmder, opts...)
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+
+	return port
+}
+
+func expectLine(t *testing.T, cmd *fakeCommand, expected string) {
+	t.Helper()
+
+	select {
+	case line := <-cmd.lines:
+		if line != expected {
+			t.Fatalf("got %q, expected %q", line, expected)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for %q", expected)
+	}
+}
+
+func expectRemoved(t *testing.T, fifoPath string) {
+	t.Helper()
+
+	_, err := os.Stat(path.Dir(fifoPath))
+	if !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed, got %v", path.Dir(fifoPath), err)
+	}
+}
+
+func TestStartStopServer(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder)
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := cmder.last()
+	if cmd.name != "/usr/bin/indiserver" || cmd.arg("-p") != s.port {
+		t.Fatalf("unexpected command %s %v", cmd.name, cmd.args)
+	}
+
+	_, err = s.StartDriver("indi_asi_ccd", "CCD 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmd, `start indi_asi_ccd -n "CCD 1"`)
+
+	err = s.StartRemoteDriver("Telescope", "pier", "7625")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmd, "start Telescope@pier:7625")
+
+	err = s.StopDriver("Telescope@pier:7625", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmd, "stop Telescope@pier:7625")
+
+	err = s.StopServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectRemoved(t, s.fifoPath)
+}
+
+func TestStartServerContextTimeout(t *testing.T) {
+	cmder := &fakeCommander{ignoreFIFO: true}
+	s := newTestServer(t, afero.NewOsFs(), cmder)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := s.StartServerContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, expected context.DeadlineExceeded", err)
+	}
+
+	if s.proc != nil {
+		t.Fatal("expected no running process")
+	}
+
+	expectRemoved(t, s.fifoPath)
+}
+
+func TestStartServerProcessExits(t *testing.T) {
+	cmder := &fakeCommander{ignoreFIFO: true}
+	s := newTestServer(t, afero.NewOsFs(), cmder)
+
+	go func() {
+		for cmder.last() == nil {
+			time.Sleep(time.Millisecond)
+		}
+
+		cmder.last().exit(errors.New("exit status 1"))
+	}()
+
+	err := s.StartServer()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if s.proc != nil {
+		t.Fatal("expected no running process")
+	}
+
+	expectRemoved(t, s.fifoPath)
+}
+
+func TestStopServerContextTimeout(t *testing.T) {
+	cmder := &fakeCommander{ignoreKill: true}
+	s := newTestServer(t, afero.NewOsFs(), cmder)
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = s.StopServerContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, expected context.DeadlineExceeded", err)
+	}
+
+	// The server is still there, so the stop can be retried.
+	if s.proc == nil {
+		t.Fatal("expected the process to be kept")
+	}
+
+	_, err = os.Stat(s.fifoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmder.last().exit(nil)
+
+	err = s.StopServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectRemoved(t, s.fifoPath)
+}
+
+func TestStopServerTerminates(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder)
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.StopServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signals := cmder.last().receivedSignals()
+	if len(signals) != 1 || signals[0] != syscall.SIGTERM {
+		t.Fatalf("got signals %v, expected SIGTERM", signals)
+	}
+}
+
+func TestStopServerEscalatesToKill(t *testing.T) {
+	cmder := &fakeCommander{ignoreSignal: true}
+	s := newTestServer(t, afero.NewOsFs(), cmder, StopTimeout(20*time.Millisecond))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+
+	err = s.StopServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("expected StopServer to wait for the stop timeout")
+	}
+
+	expectRemoved(t, s.fifoPath)
+}
+
+func TestStopServerWhileStarting(t *testing.T) {
+	cmder := &fakeCommander{ignoreFIFO: true}
+	s := newTestServer(t, afero.NewOsFs(), cmder)
+
+	started := make(chan error, 1)
+	go func() {
+		started <- s.StartServer()
+	}()
+
+	waitFor(t, "indiserver to be launched", func() bool { return cmder.count() == 1 })
+
+	// A hung indiserver must not block other calls.
+	if s.Status().Running {
+		t.Fatal("expected the server not to be running yet")
+	}
+
+	if err := s.StartServer(); err != errStarting {
+		t.Fatalf("got %v, expected errStarting", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := s.StopServerContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = <-started
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, expected context.Canceled", err)
+	}
+
+	if s.Status().Running {
+		t.Fatal("expected no running server")
+	}
+
+	expectRemoved(t, s.fifoPath)
+}
@@ -0,0 +1,182 @@
+package indiserver
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// LogFile returns the log file indiserver is currently writing to in the directory set with
+// LogDir, or an empty string if LogDir isn't set. indiserver starts a new file every day
+// (UTC).
+func (s *INDIServer) LogFile() string {
+	return s.logFileAt(time.Now())
+}
+
+func (s *INDIServer) logFileAt(t time.Time) string {
+	if len(s.logDir) == 0 {
+		return ""
+	}
+
+	return path.Join(s.logDir, t.UTC().Format("2006-01-02")+".islog")
+}
+
+// DriverLogDir returns the directory INDI drivers write their own log files to when file
+// logging is enabled on a device. Logs are stored as <dir>/<date>/<driver>/<driver>_<time>.log.
+func DriverLogDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(filepath.ToSlash(home), ".indi", "logs"), nil
+}
+
+// DriverLogFiles returns the log files written by the given driver binary (e.g. indi_asi_ccd),
+// oldest first.
+func (s *INDIServer) DriverLogFiles(driver string) ([]string, error) {
+	if len(driver) == 0 || strings.ContainsAny(driver, "/*?[\\") {
+		return nil, fmt.Errorf("invalid driver %q", driver)
+	}
+
+	dir, err := DriverLogDir()
+	if err != nil {
+		s.log.WithError(err).Warn("error in DriverLogDir")
+		return nil, err
+	}
+
+	files, err := afero.Glob(s.fs, path.Join(dir, "*", driver, "*.log"))
+	if err != nil {
+		s.log.WithError(err).Warn("error in afero.Glob")
+		return nil, err
+	}
+
+	// Both the date directories and the time in the file names sort chronologically.
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// TailDriverLog returns up to the last n lines of the most recent log file written by the
+// given driver binary.
+func (s *INDIServer) TailDriverLog(driver string, n int) ([]string, error) {
+	files, err := s.DriverLogFiles(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no log files found for %s", driver)
+	}
+
+	f, err := s.fs.Open(files[len(files)-1])
+	if err != nil {
+		s.log.WithError(err).Warn("error in s.fs.Open")
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := []string{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		s.log.WithError(err).Warn("error in scanner.Scan")
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// BundleDriverLogs writes a gzipped tar archive of every log file written by the given driver
+// binary to w. Files are stored relative to DriverLogDir.
+func (s *INDIServer) BundleDriverLogs(driver string, w io.Writer) error {
+	files, err := s.DriverLogFiles(driver)
+	if err != nil {
+		return err
+	}
+
+	dir, err := DriverLogDir()
+	if err != nil {
+		s.log.WithError(err).Warn("error in DriverLogDir")
+		return err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	for _, fp := range files {
+		err = s.addToBundle(tw, dir, fp)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		s.log.WithError(err).Warn("error in tw.Close")
+		return err
+	}
+
+	err = gw.Close()
+	if err != nil {
+		s.log.WithError(err).Warn("error in gw.Close")
+		return err
+	}
+
+	return nil
+}
+
+func (s *INDIServer) addToBundle(tw *tar.Writer, dir, fp string) error {
+	f, err := s.fs.Open(fp)
+	if err != nil {
+		s.log.WithError(err).Warn("error in s.fs.Open")
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		s.log.WithError(err).Warn("error in f.Stat")
+		return err
+	}
+
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		s.log.WithError(err).Warn("error in tar.FileInfoHeader")
+		return err
+	}
+
+	hdr.Name = strings.TrimPrefix(strings.TrimPrefix(fp, dir), "/")
+
+	err = tw.WriteHeader(hdr)
+	if err != nil {
+		s.log.WithError(err).Warn("error in tw.WriteHeader")
+		return err
+	}
+
+	_, err = io.Copy(tw, f)
+	if err != nil {
+		s.log.WithError(err).Warn("error in io.Copy")
+		return err
+	}
+
+	return nil
+}
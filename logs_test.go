@@ -0,0 +1,105 @@
+package indiserver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rickbassham/goexec"
+	"github.com/spf13/afero"
+)
+
+func TestDriverLogs(t *testing.T) {
+	home := os.Getenv("HOME")
+	os.Setenv("HOME", "/home/astro")
+	defer os.Setenv("HOME", home)
+
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/home/astro/.indi/logs/2020-01-02/indi_asi_ccd/indi_asi_ccd_01:00:00.log", []byte("c\nd\ne\n"), 0644)
+	afero.WriteFile(fs, "/home/astro/.indi/logs/2020-01-01/indi_asi_ccd/indi_asi_ccd_23:00:00.log", []byte("a\nb\n"), 0644)
+	afero.WriteFile(fs, "/home/astro/.indi/logs/2020-01-02/indi_eqmod_telescope/indi_eqmod_telescope_01:00:00.log", []byte("x\n"), 0644)
+
+	s := newTestServer(t, fs, goexec.ExecCommand{})
+
+	files, err := s.DriverLogFiles("indi_asi_ccd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"/home/astro/.indi/logs/2020-01-01/indi_asi_ccd/indi_asi_ccd_23:00:00.log",
+		"/home/astro/.indi/logs/2020-01-02/indi_asi_ccd/indi_asi_ccd_01:00:00.log",
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Fatalf("got %v, expected %v", files, expected)
+	}
+
+	lines, err := s.TailDriverLog("indi_asi_ccd", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(lines, []string{"d", "e"}) {
+		t.Fatalf("got %v", lines)
+	}
+
+	var buf bytes.Buffer
+	err = s.BundleDriverLogs("indi_asi_ccd", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+
+	expected = []string{
+		"2020-01-01/indi_asi_ccd/indi_asi_ccd_23:00:00.log",
+		"2020-01-02/indi_asi_ccd/indi_asi_ccd_01:00:00.log",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("got %v, expected %v", names, expected)
+	}
+
+	_, err = s.DriverLogFiles("../etc")
+	if err == nil {
+		t.Fatal("expected error for invalid driver")
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	s := newTestServer(t, afero.NewMemMapFs(), goexec.ExecCommand{})
+
+	if s.LogFile() != "" {
+		t.Fatalf("expected no log file without LogDir, got %q", s.LogFile())
+	}
+
+	LogDir("/var/log/indi")(s)
+
+	// 10 PM in New York is already the next day in UTC, which indiserver uses.
+	at := time.Date(2020, 3, 4, 22, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+
+	actual := s.logFileAt(at)
+	if actual != "/var/log/indi/2020-03-05.islog" {
+		t.Fatalf("got %q", actual)
+	}
+}
@@ -0,0 +1,185 @@
+package indiserver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rickbassham/logging"
+	"github.com/spf13/afero"
+)
+
+// ServerManager runs and tracks several INDIServers on different ports, e.g. one per
+// telescope pier.
+type ServerManager struct {
+	log   logging.Logger
+	fs    afero.Fs
+	cmder Commander
+	opts  []Option
+
+	mu      sync.Mutex
+	servers map[string]*INDIServer
+}
+
+// NewServerManager creates a ServerManager whose servers use log, fs and cmder. opts are
+// applied to every server before the options given to Add.
+func NewServerManager(log logging.Logger, fs afero.Fs, cmder Commander, opts ...Option) *ServerManager {
+	return &ServerManager{
+		log:     log,
+		fs:      fs,
+		cmder:   cmder,
+		opts:    opts,
+		servers: map[string]*INDIServer{},
+	}
+}
+
+// ManagerError holds the errors from a ServerManager operation, by server port.
+type ManagerError map[string]error
+
+func (e ManagerError) Error() string {
+	ports := make([]string, 0, len(e))
+	for port := range e {
+		ports = append(ports, port)
+	}
+	sort.Strings(ports)
+
+	msgs := make([]string, 0, len(ports))
+	for _, port := range ports {
+		msgs = append(msgs, fmt.Sprintf("port %s: %s", port, e[port]))
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Add creates a server on port. It doesn't start it. It is an error to use a port twice.
+func (m *ServerManager) Add(port string, opts ...Option) (*INDIServer, error) {
+	if len(port) == 0 {
+		return nil, fmt.Errorf("empty port")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.servers[port]; ok {
+		return nil, fmt.Errorf("a server on port %s already exists", port)
+	}
+
+	s := NewINDIServer(m.log.WithField("port", port), m.fs, port, m.cmder, append(append([]Option{}, m.opts...), opts...)...)
+	m.servers[port] = s
+
+	return s, nil
+}
+
+// Remove stops the server on port and stops tracking it.
+func (m *ServerManager) Remove(port string) error {
+	m.mu.Lock()
+	s, ok := m.servers[port]
+	delete(m.servers, port)
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no server on port %s", port)
+	}
+
+	return s.StopServer()
+}
+
+// Server returns the server on port, if there is one.
+func (m *ServerManager) Server(port string) (*INDIServer, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.servers[port]
+
+	return s, ok
+}
+
+// Servers returns all servers by port.
+func (m *ServerManager) Servers() map[string]*INDIServer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	servers := make(map[string]*INDIServer, len(m.servers))
+	for port, s := range m.servers {
+		servers[port] = s
+	}
+
+	return servers
+}
+
+// Drivers returns the drivers known to any of the servers, organized by group.
+func (m *ServerManager) Drivers() map[string][]Driver {
+	drivers := map[string][]Driver{}
+	seen := map[string]map[Driver]bool{}
+
+	for _, s := range m.Servers() {
+		for group, list := range s.Drivers() {
+			if seen[group] == nil {
+				seen[group] = map[Driver]bool{}
+			}
+
+			for _, d := range list {
+				if !seen[group][d] {
+					seen[group][d] = true
+					drivers[group] = append(drivers[group], d)
+				}
+			}
+		}
+	}
+
+	return drivers
+}
+
+// StartAll starts every server that isn't running yet. Errors are returned as a ManagerError.
+func (m *ServerManager) StartAll() error {
+	return m.each((*INDIServer).StartServer)
+}
+
+// StopAll stops every server. Errors are returned as a ManagerError.
+func (m *ServerManager) StopAll() error {
+	return m.each((*INDIServer).StopServer)
+}
+
+// StatusAll returns the status of every server by port.
+func (m *ServerManager) StatusAll() map[string]Status {
+	statuses := map[string]Status{}
+
+	for port, s := range m.Servers() {
+		statuses[port] = s.Status()
+	}
+
+	return statuses
+}
+
+// each calls f on every server concurrently and collects the errors.
+func (m *ServerManager) each(f func(*INDIServer) error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = ManagerError{}
+	)
+
+	for port, s := range m.Servers() {
+		wg.Add(1)
+
+		go func(port string, s *INDIServer) {
+			defer wg.Done()
+
+			err := f(s)
+			if err != nil {
+				mu.Lock()
+				errs[port] = err
+				mu.Unlock()
+			}
+		}(port, s)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package indiserver
+
+import (
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rickbassham/logging"
+	"github.com/spf13/afero"
+)
+
+func TestServerManager(t *testing.T) {
+	fs := afero.NewOsFs()
+	logger := logging.NewLogger(ioutil.Discard, logging.JSONFormatter{}, logging.LogLevelInfo)
+
+	cmder := &fakeCommander{}
+	m := NewServerManager(logger, fs, cmder, StopTimeout(0))
+
+	eastPort, westPort := freePort(t), freePort(t)
+
+	east, err := m.Add(eastPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.Add(westPort, DriverRestarts(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.Add(eastPort)
+	if err == nil {
+		t.Fatal("expected an error adding a port twice")
+	}
+
+	err = m.StartAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cmder.count() != 2 {
+		t.Fatalf("expected 2 servers, got %d", cmder.count())
+	}
+
+	_, err = east.StartDriver("indi_eqmod_telescope", "EQMod Mount")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := m.StatusAll()
+	if len(statuses) != 2 || !statuses[eastPort].Running || !statuses[westPort].Running {
+		t.Fatalf("unexpected statuses %+v", statuses)
+	}
+
+	if statuses[westPort].DriverRestarts != 2 {
+		t.Fatalf("expected per-server options to apply, got %+v", statuses[westPort])
+	}
+
+	if !reflect.DeepEqual(statuses[eastPort].Drivers, []DriverSpec{{Driver: "indi_eqmod_telescope", Name: "EQMod Mount"}}) {
+		t.Fatalf("unexpected drivers %+v", statuses[eastPort].Drivers)
+	}
+
+	err = m.StopAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for port, st := range m.StatusAll() {
+		if st.Running {
+			t.Fatalf("expected %s to be stopped", port)
+		}
+	}
+
+	err = m.Remove(westPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := m.Server(westPort); ok {
+		t.Fatalf("expected %s to be removed", westPort)
+	}
+}
+
+func TestManagerError(t *testing.T) {
+	err := ManagerError{"7625": errors.New("b"), "7624": errors.New("a")}
+
+	if !strings.HasPrefix(err.Error(), "port 7624: a; port 7625: b") {
+		t.Fatalf("got %q", err.Error())
+	}
+}
+
+func TestServerManagerDrivers(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/usr/share/indi/drivers.xml", []byte(`<driversList>
+<devGroup group="CCDs">
+<device label="ZWO CCD"><driver>indi_asi_ccd</driver><version>1.0</version></device>
+</devGroup>
+</driversList>`), 0644)
+
+	logger := logging.NewLogger(ioutil.Discard, logging.JSONFormatter{}, logging.LogLevelInfo)
+	m := NewServerManager(logger, fs, &fakeCommander{})
+
+	m.Add("7624")
+	m.Add("7625")
+
+	expected := map[string][]Driver{"CCDs": {{Label: "ZWO CCD", Driver: "indi_asi_ccd", Version: "1.0"}}}
+	if !reflect.DeepEqual(m.Drivers(), expected) {
+		t.Fatalf("got %+v", m.Drivers())
+	}
+}
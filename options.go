@@ -0,0 +1,123 @@
+package indiserver
+
+import (
+	"os"
+	"time"
+)
+
+// DefaultBinary is the indiserver binary that is run unless changed with Binary.
+const DefaultBinary = "/usr/bin/indiserver"
+
+// DefaultStopTimeout is how long StopServer waits for indiserver to exit after SIGTERM before
+// killing it, unless changed with StopTimeout.
+const DefaultStopTimeout = 5 * time.Second
+
+// DefaultDriverTimeout is how long a driver gets to come up when its start is verified, unless
+// changed with DriverTimeout.
+const DefaultDriverTimeout = 10 * time.Second
+
+// Option configures optional behavior of an INDIServer. Pass options to NewINDIServer.
+type Option func(*INDIServer)
+
+// FIFOMode sets the permission bits of the control FIFO. By default the FIFO is created
+// with 0666 minus the process umask inside a directory only the current user can enter.
+// When a mode is set, the FIFO gets exactly that mode and its directory is made searchable
+// by every class (group, other) the mode grants access to.
+func FIFOMode(mode os.FileMode) Option {
+	return func(s *INDIServer) {
+		s.fifoMode = mode.Perm()
+	}
+}
+
+// FIFOOwner sets the owning user and group of the control FIFO and its directory. Pass -1
+// for either to leave it unchanged. Changing the owner usually requires root; changing the
+// group requires the current user to be a member of it.
+func FIFOOwner(uid, gid int) Option {
+	return func(s *INDIServer) {
+		s.fifoUID = uid
+		s.fifoGID = gid
+	}
+}
+
+// StopTimeout sets how long StopServer waits for indiserver to exit after SIGTERM, giving
+// drivers a chance to close their devices cleanly, before killing it. Zero kills it right
+// away.
+func StopTimeout(d time.Duration) Option {
+	return func(s *INDIServer) {
+		s.stopTimeout = d
+	}
+}
+
+// DriverTimeout sets how long a driver gets to come up when its start is verified, like in
+// RestartDriver.
+func DriverTimeout(d time.Duration) Option {
+	return func(s *INDIServer) {
+		s.driverTimeout = d
+	}
+}
+
+// Binary sets the indiserver binary to run, for systems where it is installed somewhere
+// other than DefaultBinary. A bare name like "indiserver" is looked up in $PATH when the
+// server starts.
+func Binary(path string) Option {
+	return func(s *INDIServer) {
+		s.binary = path
+	}
+}
+
+// ExtraArgs appends args to the indiserver command line, after the arguments the INDIServer
+// sets itself.
+func ExtraArgs(args ...string) Option {
+	return func(s *INDIServer) {
+		s.extraArgs = append(s.extraArgs, args...)
+	}
+}
+
+// MaxClientMB sets how many MB a client may fall behind before indiserver disconnects it
+// (indiserver's -m flag, 128 by default). Raise it for slow network clients downloading large
+// CCD BLOBs.
+func MaxClientMB(mb int) Option {
+	return func(s *INDIServer) {
+		s.maxClientMB = mb
+	}
+}
+
+// MaxStreamMB sets how many MB of streaming BLOBs a client may fall behind before
+// indiserver starts dropping frames for it instead of buffering them (indiserver's -d flag,
+// 5 by default). Zero disables dropping.
+func MaxStreamMB(mb int) Option {
+	return func(s *INDIServer) {
+		s.maxStreamMB = mb
+	}
+}
+
+// LogDir makes indiserver write its own log files to dir (indiserver's -l flag). Use
+// LogFile to find the current one.
+func LogDir(dir string) Option {
+	return func(s *INDIServer) {
+		s.logDir = dir
+	}
+}
+
+// Verbosity sets how much indiserver logs, from 0 (no -v flag) to 3 (-vvv, which includes
+// all XML traffic). The default is 1 (-v).
+func Verbosity(level int) Option {
+	return func(s *INDIServer) {
+		if level < 0 {
+			level = 0
+		}
+		if level > 3 {
+			level = 3
+		}
+
+		s.verbosity = level
+	}
+}
+
+// DriverRestarts sets how many times indiserver restarts a crashed driver before giving up on
+// it (indiserver's -r flag, 10 by default). Zero disables driver restarts.
+func DriverRestarts(n int) Option {
+	return func(s *INDIServer) {
+		s.driverRestarts = n
+	}
+}
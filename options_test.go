@@ -0,0 +1,185 @@
+package indiserver
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/rickbassham/goexec"
+	"github.com/spf13/afero"
+)
+
+func newTestFIFO(t *testing.T, s *INDIServer) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.fifoPath = dir + "/fifo"
+
+	err = syscall.Mkfifo(s.fifoPath, 0666)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestFIFOMode(t *testing.T) {
+	s := newTestServer(t, afero.NewOsFs(), goexec.ExecCommand{})
+	FIFOMode(0620)(s)
+
+	dir := newTestFIFO(t, s)
+	defer os.RemoveAll(dir)
+
+	err := s.setFIFOPermissions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(s.fifoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0620 {
+		t.Fatalf("fifo mode = %o", info.Mode().Perm())
+	}
+
+	info, err = os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0710 {
+		t.Fatalf("dir mode = %o", info.Mode().Perm())
+	}
+}
+
+func TestFIFOOwner(t *testing.T) {
+	uid, gid := os.Getuid(), os.Getgid()
+
+	s := newTestServer(t, afero.NewOsFs(), goexec.ExecCommand{})
+	FIFOOwner(uid, gid)(s)
+
+	dir := newTestFIFO(t, s)
+	defer os.RemoveAll(dir)
+
+	err := s.setFIFOPermissions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{dir, s.fifoPath} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		st := info.Sys().(*syscall.Stat_t)
+		if int(st.Uid) != uid || int(st.Gid) != gid {
+			t.Fatalf("%s owned by %d:%d, expected %d:%d", p, st.Uid, st.Gid, uid, gid)
+		}
+	}
+}
+
+func TestBinaryAndExtraArgs(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, Binary("/opt/indi/bin/indiserver"), ExtraArgs("-x"), ExtraArgs("-m", "50"))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	cmd := cmder.last()
+	if cmd.name != "/opt/indi/bin/indiserver" {
+		t.Fatalf("got binary %s", cmd.name)
+	}
+
+	expected := []string{"-v", "-f", s.fifoPath, "-p", s.port, "-x", "-m", "50"}
+	if !reflect.DeepEqual(cmd.args, expected) {
+		t.Fatalf("got args %q, expected %q", cmd.args, expected)
+	}
+}
+
+func TestBinaryFromPath(t *testing.T) {
+	s := newTestServer(t, afero.NewOsFs(), &fakeCommander{}, Binary("sh"))
+
+	binary, err := s.resolveBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(binary, "/sh") {
+		t.Fatalf("got %s", binary)
+	}
+
+	s = newTestServer(t, afero.NewOsFs(), &fakeCommander{}, Binary("no-such-indiserver"))
+
+	err = s.StartServer()
+	if err == nil {
+		t.Fatal("expected an error for a missing binary")
+	}
+
+	expectRemoved(t, s.fifoPath)
+}
+
+func TestServerFlags(t *testing.T) {
+	tests := []struct {
+		opts     []Option
+		expected []string
+	}{
+		{nil, []string{}},
+		{[]Option{MaxClientMB(256)}, []string{"-m", "256"}},
+		{[]Option{MaxStreamMB(0)}, []string{"-d", "0"}},
+		{[]Option{MaxClientMB(256), MaxStreamMB(20)}, []string{"-m", "256", "-d", "20"}},
+		{[]Option{LogDir("/var/log/indi")}, []string{"-l", "/var/log/indi"}},
+		{[]Option{DriverRestarts(0)}, []string{"-r", "0"}},
+	}
+
+	for _, test := range tests {
+		s := newTestServer(t, afero.NewOsFs(), &fakeCommander{}, test.opts...)
+		s.fifoPath = "/tmp/fifo"
+		s.port = "7624"
+
+		expected := append([]string{"-v", "-f", "/tmp/fifo", "-p", "7624"}, test.expected...)
+
+		actual := s.args()
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got %q, expected %q", actual, expected)
+		}
+	}
+}
+
+func TestVerbosity(t *testing.T) {
+	tests := []struct {
+		level    int
+		expected []string
+	}{
+		{-1, []string{"-f", "/tmp/fifo", "-p", "7624"}},
+		{0, []string{"-f", "/tmp/fifo", "-p", "7624"}},
+		{1, []string{"-v", "-f", "/tmp/fifo", "-p", "7624"}},
+		{2, []string{"-vv", "-f", "/tmp/fifo", "-p", "7624"}},
+		{3, []string{"-vvv", "-f", "/tmp/fifo", "-p", "7624"}},
+		{4, []string{"-vvv", "-f", "/tmp/fifo", "-p", "7624"}},
+	}
+
+	for _, test := range tests {
+		s := newTestServer(t, afero.NewOsFs(), &fakeCommander{}, Verbosity(test.level))
+		s.fifoPath = "/tmp/fifo"
+		s.port = "7624"
+
+		actual := s.args()
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("level %d: got %q, expected %q", test.level, actual, test.expected)
+		}
+	}
+}
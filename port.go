@@ -0,0 +1,48 @@
+package indiserver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// ErrPortInUse is returned when starting an indiserver on a TCP port that is already bound.
+// The returned error is a *PortInUseError, and matches ErrPortInUse with errors.Is.
+var ErrPortInUse = errors.New("port is already in use")
+
+// PortInUseError is returned when starting an indiserver on a TCP port that is already bound.
+type PortInUseError struct {
+	Port string
+
+	// PID is the process listening on the port, or 0 if it couldn't be found.
+	PID int
+}
+
+func (e *PortInUseError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("port %s is already in use by pid %d", e.Port, e.PID)
+	}
+
+	return fmt.Sprintf("port %s is already in use", e.Port)
+}
+
+// Is makes a PortInUseError match ErrPortInUse.
+func (e *PortInUseError) Is(target error) bool {
+	return target == ErrPortInUse
+}
+
+// checkPort makes sure the port is free before starting indiserver, which would otherwise
+// only log that it couldn't bind.
+func (s *INDIServer) checkPort() error {
+	l, err := net.Listen("tcp", ":"+s.port)
+	if errors.Is(err, syscall.EADDRINUSE) {
+		err = &PortInUseError{Port: s.port, PID: portOwner(s.port)}
+	}
+	if err != nil {
+		s.log.WithError(err).Warn("error in net.Listen")
+		return err
+	}
+
+	return l.Close()
+}
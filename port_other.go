@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package indiserver
+
+// portOwner is only supported on Linux, where sockets can be found through /proc.
+func portOwner(port string) int {
+	return 0
+}
@@ -0,0 +1,76 @@
+package indiserver
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/rickbassham/logging"
+	"github.com/spf13/afero"
+)
+
+func TestStartServerPortInUse(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+
+	logger := logging.NewLogger(ioutil.Discard, logging.JSONFormatter{}, logging.LogLevelInfo)
+	cmder := &fakeCommander{}
+	s := NewINDIServer(logger, afero.NewOsFs(), port, cmder)
+
+	err = s.StartServer()
+	if !errors.Is(err, ErrPortInUse) {
+		t.Fatalf("expected ErrPortInUse, got %v", err)
+	}
+
+	var portErr *PortInUseError
+	if !errors.As(err, &portErr) || portErr.Port != port {
+		t.Fatalf("unexpected error %#v", err)
+	}
+
+	if runtime.GOOS == "linux" && portErr.PID != os.Getpid() {
+		t.Fatalf("expected pid %d, got %d", os.Getpid(), portErr.PID)
+	}
+
+	if cmder.count() != 0 {
+		t.Fatal("expected indiserver not to be started")
+	}
+
+	l.Close()
+
+	err = s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.StopServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartServerBadPort(t *testing.T) {
+	s := newTestServer(t, afero.NewOsFs(), &fakeCommander{})
+	s.port = "no-such-port"
+
+	err := s.StartServer()
+	if err == nil || errors.Is(err, ErrPortInUse) {
+		t.Fatalf("expected the listen error, got %v", err)
+	}
+}
+
+func TestDefaultPort(t *testing.T) {
+	logger := logging.NewLogger(ioutil.Discard, logging.JSONFormatter{}, logging.LogLevelInfo)
+	s := NewINDIServer(logger, afero.NewMemMapFs(), "", &fakeCommander{})
+
+	if s.port != "7624" {
+		t.Fatalf("got port %q, expected 7624", s.port)
+	}
+}
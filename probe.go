@@ -0,0 +1,80 @@
+package indiserver
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// probeDevice connects to indiserver and asks for the properties of device until the device
+// defines one, which means its driver is up.
+func (s *INDIServer) probeDevice(device string, timeout time.Duration) error {
+	if len(device) == 0 {
+		return errors.New("verifying a driver through its properties needs a device name")
+	}
+
+	addr := net.JoinHostPort("localhost", s.port)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		err := s.probeOnce(addr, device, deadline)
+		if err == nil {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			s.log.WithError(err).WithField("device", device).Warn("device not defined")
+			return fmt.Errorf("device %q not defined after %s: %v", device, timeout, err)
+		}
+
+		time.Sleep(readyPollInterval)
+	}
+}
+
+func (s *INDIServer) probeOnce(addr, device string, deadline time.Time) error {
+	conn, err := net.DialTimeout("tcp", addr, time.Until(deadline))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(device))
+
+	_, err = fmt.Fprintf(conn, `<getProperties version="1.7" device="%s"/>`+"\n", b.String())
+	if err != nil {
+		return err
+	}
+
+	dec := xml.NewDecoder(conn)
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+
+		el, ok := tok.(xml.StartElement)
+		if !ok || !strings.HasPrefix(el.Name.Local, "def") {
+			continue
+		}
+
+		for _, a := range el.Attr {
+			if a.Name.Local == "device" && a.Value == device {
+				return nil
+			}
+		}
+	}
+}
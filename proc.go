@@ -0,0 +1,221 @@
+package indiserver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/rickbassham/goexec"
+)
+
+// errUnsupportedCommand is returned when an option needs more control over the process than
+// the Commander's commands offer.
+var errUnsupportedCommand = errors.New("the Commander does not support this option; use ExecCommander")
+
+// sysProcAttrCommand is implemented by commands whose OS specific process attributes can be
+// set before they are started.
+type sysProcAttrCommand interface {
+	SetSysProcAttr(attr *syscall.SysProcAttr)
+}
+
+// envCommand is implemented by commands whose environment can be set before they are started.
+type envCommand interface {
+	SetEnv(env []string)
+}
+
+// Credential runs indiserver, and so every driver, as the given user and group, with groups
+// as its supplementary groups. The calling process needs the privileges to switch to them,
+// for example a management daemon running as root starting indiserver as an astro user.
+// Unless FIFOOwner or FIFOMode is set, the FIFO and its directory are given to that user and
+// group, so indiserver can open it.
+func Credential(uid, gid uint32, groups ...uint32) Option {
+	return func(s *INDIServer) {
+		s.credential = &syscall.Credential{
+			Uid:    uid,
+			Gid:    gid,
+			Groups: groups,
+		}
+	}
+}
+
+// Nice sets the niceness of the indiserver process, which its drivers inherit. Lowering it
+// below the current value needs privileges.
+func Nice(n int) Option {
+	return func(s *INDIServer) {
+		s.nice = &n
+	}
+}
+
+// Env sets environment variables, given as "KEY=value", on the indiserver process in addition
+// to the current environment. Drivers inherit them, so they can be used for variables like
+// INDIPREFIX or QHYCCD_FIRMWARE_DIR that drivers read their configuration from.
+func Env(vars ...string) Option {
+	return func(s *INDIServer) {
+		s.env = append(s.env, vars...)
+	}
+}
+
+// ProcessGroup runs indiserver in a new process group, which its drivers join. StopServer
+// then sends SIGTERM to the whole group and kills whatever is left of it after the stop
+// timeout, and the same happens to the group of an indiserver that exits on its own, so
+// drivers don't stay behind as orphans holding serial ports open. The group also no longer
+// receives signals from the terminal, like Ctrl-C.
+func ProcessGroup() Option {
+	return func(s *INDIServer) {
+		s.processGroup = true
+	}
+}
+
+// configureProcess applies the process attributes set with options to cmd before it starts.
+func (s *INDIServer) configureProcess(cmd goexec.Command) error {
+	err := checkRlimits(s.rlimits)
+	if err != nil {
+		return err
+	}
+
+	if len(s.env) > 0 {
+		ec, ok := cmd.(envCommand)
+		if !ok {
+			return errUnsupportedCommand
+		}
+
+		// Later entries win, so ours override the inherited ones.
+		ec.SetEnv(append(os.Environ(), s.env...))
+	}
+
+	if s.credential == nil && !s.processGroup {
+		return nil
+	}
+
+	ac, ok := cmd.(sysProcAttrCommand)
+	if !ok {
+		return errUnsupportedCommand
+	}
+
+	if _, ok := cmd.(groupCommand); s.processGroup && !ok {
+		return errUnsupportedCommand
+	}
+
+	ac.SetSysProcAttr(&syscall.SysProcAttr{
+		Credential: s.credential,
+		Setpgid:    s.processGroup,
+	})
+
+	return nil
+}
+
+// kill kills cmd, and its process group if ProcessGroup is set.
+func (s *INDIServer) kill(cmd goexec.Command) error {
+	if !s.processGroup {
+		return cmd.Kill()
+	}
+
+	err := cmd.(groupCommand).SignalGroup(syscall.SIGKILL)
+	if err != nil && err != syscall.ESRCH {
+		s.log.WithError(err).Warn("error in SignalGroup")
+	}
+
+	return cmd.Kill()
+}
+
+// stopGroup asks p's process group to exit with SIGTERM, waits until the stop timeout for p
+// to exit and the rest of the group to follow, then kills whatever is left. It works after p
+// has already exited, to clean up drivers it left behind.
+func (s *INDIServer) stopGroup(ctx context.Context, p *process) error {
+	gc := p.cmd.(groupCommand)
+
+	if s.stopTimeout > 0 && groupAlive(gc) {
+		err := gc.SignalGroup(syscall.SIGTERM)
+		if err != nil {
+			s.log.WithError(err).Warn("error in SignalGroup")
+		} else if s.waitForGroup(ctx, p, gc) {
+			return nil
+		}
+	}
+
+	if groupAlive(gc) {
+		s.log.Warn("indiserver process group did not exit, killing it")
+
+		err := gc.SignalGroup(syscall.SIGKILL)
+		if err != nil && err != syscall.ESRCH {
+			s.log.WithError(err).Warn("error in SignalGroup")
+			return err
+		}
+	}
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		s.log.WithError(ctx.Err()).Warn("indiserver did not exit")
+		return ctx.Err()
+	}
+}
+
+// waitForGroup waits until p has exited and its process group is empty. It returns false if
+// the stop timeout passes or ctx is done first.
+func (s *INDIServer) waitForGroup(ctx context.Context, p *process, gc groupCommand) bool {
+	timer := time.NewTimer(s.stopTimeout)
+	defer timer.Stop()
+
+	ticker := time.NewTicker(fifoPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			if !groupAlive(gc) {
+				return true
+			}
+		default:
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// groupAlive reports whether any process of the group is left.
+func groupAlive(gc groupCommand) bool {
+	return gc.SignalGroup(0) == nil
+}
+
+// applyLimits applies the niceness and resource limits set with options to the started cmd.
+// indiserver only starts drivers once we have opened the FIFO, so they all inherit them.
+func (s *INDIServer) applyLimits(cmd goexec.Command) error {
+	if s.nice == nil && len(s.rlimits) == 0 {
+		return nil
+	}
+
+	pc, ok := cmd.(pidCommand)
+	if !ok {
+		return errUnsupportedCommand
+	}
+
+	pid := pc.Pid()
+
+	if s.nice != nil {
+		err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, *s.nice)
+		if err != nil {
+			s.log.WithError(err).Warn("error in syscall.Setpriority")
+			return err
+		}
+	}
+
+	for _, rl := range s.rlimits {
+		err := setRlimit(pid, rl)
+		if err != nil {
+			s.log.WithError(err).Warn("error in setRlimit")
+			return err
+		}
+	}
+
+	return nil
+}
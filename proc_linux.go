@@ -0,0 +1,42 @@
+package indiserver
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// rlimit is a resource limit to apply to the indiserver process.
+type rlimit struct {
+	resource int
+	limit    syscall.Rlimit
+}
+
+// Rlimit sets a resource limit (one of the syscall.RLIMIT_* values) of the indiserver
+// process, which its drivers inherit. Only supported on Linux.
+func Rlimit(resource int, cur, max uint64) Option {
+	return func(s *INDIServer) {
+		s.rlimits = append(s.rlimits, rlimit{
+			resource: resource,
+			limit:    syscall.Rlimit{Cur: cur, Max: max},
+		})
+	}
+}
+
+// checkRlimits reports whether rlimits can be applied. Linux supports them all.
+func checkRlimits(rlimits []rlimit) error {
+	return nil
+}
+
+// setRlimit sets a resource limit of another process with prlimit(2).
+func setRlimit(pid int, rl rlimit) error {
+	return prlimit(pid, rl.resource, &rl.limit, nil)
+}
+
+func prlimit(pid, resource int, limit, old *syscall.Rlimit) error {
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource), uintptr(unsafe.Pointer(limit)), uintptr(unsafe.Pointer(old)), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package indiserver
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestApplyLimits(t *testing.T) {
+	s := newTestServer(t, afero.NewOsFs(), ExecCommander{}, Nice(5), Rlimit(syscall.RLIMIT_NOFILE, 64, 64))
+
+	cmd := ExecCommander{}.Command("/bin/sleep", "10")
+
+	err := cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Wait()
+	defer cmd.Kill()
+
+	err = s.applyLimits(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pid := cmd.(pidCommand).Pid()
+
+	// getpriority(2) returns 20 - nice.
+	prio, err := syscall.Getpriority(syscall.PRIO_PROCESS, pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if 20-prio != 5 {
+		t.Fatalf("got nice %d, expected 5", 20-prio)
+	}
+
+	var limit syscall.Rlimit
+
+	err = prlimit(pid, syscall.RLIMIT_NOFILE, nil, &limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limit.Cur != 64 || limit.Max != 64 {
+		t.Fatalf("got limit %+v", limit)
+	}
+}
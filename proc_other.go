@@ -0,0 +1,39 @@
+//go:build !linux
+// +build !linux
+
+package indiserver
+
+import "errors"
+
+// errRlimit is returned by StartServer when Rlimit is used outside Linux.
+var errRlimit = errors.New("Rlimit is only supported on Linux")
+
+// rlimit is a resource limit to apply to the indiserver process. Outside Linux only the
+// request is recorded, so StartServer can refuse it.
+type rlimit struct {
+	resource int
+}
+
+// Rlimit sets a resource limit (one of the syscall.RLIMIT_* values) of the indiserver
+// process, which its drivers inherit. Only supported on Linux; elsewhere StartServer
+// returns an error.
+func Rlimit(resource int, cur, max uint64) Option {
+	return func(s *INDIServer) {
+		s.rlimits = append(s.rlimits, rlimit{resource: resource})
+	}
+}
+
+// checkRlimits refuses rlimits before indiserver is started, since only Linux can change the
+// limits of another process.
+func checkRlimits(rlimits []rlimit) error {
+	if len(rlimits) > 0 {
+		return errRlimit
+	}
+
+	return nil
+}
+
+// setRlimit is only supported on Linux.
+func setRlimit(pid int, rl rlimit) error {
+	return errRlimit
+}
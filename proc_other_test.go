@@ -0,0 +1,27 @@
+//go:build !linux
+// +build !linux
+
+package indiserver
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRlimitUnsupported(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, Rlimit(syscall.RLIMIT_NOFILE, 64, 64))
+
+	err := s.StartServer()
+	if err != errRlimit {
+		t.Fatalf("got %v, expected errRlimit", err)
+	}
+
+	if s.Status().Running {
+		t.Fatal("expected indiserver not to be running")
+	}
+
+	expectRemoved(t, s.fifoPath)
+}
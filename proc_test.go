@@ -0,0 +1,163 @@
+package indiserver
+
+import (
+	"errors"
+	"os"
+	"path"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestCredential(t *testing.T) {
+	cmder := &fakeCommander{}
+	uid, gid := uint32(os.Getuid()), uint32(os.Getgid())
+	s := newTestServer(t, afero.NewOsFs(), cmder, Credential(uid, gid, 20))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	cred := cmder.last().sysProcAttr.Credential
+	if cred.Uid != uid || cred.Gid != gid || len(cred.Groups) != 1 || cred.Groups[0] != 20 {
+		t.Fatalf("unexpected credential %+v", cred)
+	}
+}
+
+func TestUnsupportedCommander(t *testing.T) {
+	s := newTestServer(t, afero.NewOsFs(), goexecCommander{}, Credential(1000, 1000))
+
+	err := s.StartServer()
+	if err != errUnsupportedCommand {
+		t.Fatalf("got %v, expected errUnsupportedCommand", err)
+	}
+
+	expectRemoved(t, s.fifoPath)
+}
+
+func TestProcessGroup(t *testing.T) {
+	uid, gid := uint32(os.Getuid()), uint32(os.Getgid())
+
+	tests := []struct {
+		name     string
+		cmder    *fakeCommander
+		expected []syscall.Signal
+	}{
+		{"exits on SIGTERM", &fakeCommander{}, []syscall.Signal{syscall.SIGTERM}},
+		{"ignores SIGTERM", &fakeCommander{ignoreSignal: true}, []syscall.Signal{syscall.SIGTERM, syscall.SIGKILL}},
+		{"leaves orphans", &fakeCommander{orphans: true}, []syscall.Signal{syscall.SIGTERM, syscall.SIGKILL}},
+	}
+
+	for _, test := range tests {
+		s := newTestServer(t, afero.NewOsFs(), test.cmder, ProcessGroup(), Credential(uid, gid), StopTimeout(20*time.Millisecond))
+
+		err := s.StartServer()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := test.cmder.last()
+
+		attr := cmd.sysProcAttr
+		if !attr.Setpgid || attr.Credential == nil || attr.Credential.Uid != uid {
+			t.Fatalf("%s: unexpected attributes %+v", test.name, attr)
+		}
+
+		err = s.StopServer()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(cmd.receivedGroupSignals(), test.expected) {
+			t.Errorf("%s: got group signals %v, expected %v", test.name, cmd.receivedGroupSignals(), test.expected)
+		}
+	}
+}
+
+func TestProcessGroupAfterExit(t *testing.T) {
+	cmder := &fakeCommander{orphans: true}
+	s := newTestServer(t, afero.NewOsFs(), cmder, ProcessGroup(), StopTimeout(20*time.Millisecond))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := cmder.last()
+	cmd.exit(errors.New("signal: segmentation fault"))
+
+	expectDone(t, s)
+
+	expected := []syscall.Signal{syscall.SIGTERM, syscall.SIGKILL}
+	if !reflect.DeepEqual(cmd.receivedGroupSignals(), expected) {
+		t.Fatalf("got group signals %v, expected %v", cmd.receivedGroupSignals(), expected)
+	}
+}
+
+func TestProcessGroupUnsupportedCommander(t *testing.T) {
+	s := newTestServer(t, afero.NewOsFs(), goexecCommander{}, ProcessGroup())
+
+	err := s.StartServer()
+	if err != errUnsupportedCommand {
+		t.Fatalf("got %v, expected errUnsupportedCommand", err)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, Env("INDIPREFIX=/opt/indi"), Env("LANG=C"))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	env := cmder.last().env
+	if len(env) != len(os.Environ())+2 {
+		t.Fatalf("expected the current environment to be kept, got %q", env)
+	}
+
+	if !reflect.DeepEqual(env[len(env)-2:], []string{"INDIPREFIX=/opt/indi", "LANG=C"}) {
+		t.Fatalf("unexpected environment %q", env)
+	}
+}
+
+func TestCredentialFIFOOwner(t *testing.T) {
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid == 0 {
+		// Root can give the FIFO away, which is the case Credential is meant for.
+		uid, gid = 1000, 1000
+	}
+
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, Credential(uint32(uid), uint32(gid)))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	for _, p := range []string{path.Dir(s.fifoPath), s.fifoPath} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		st := info.Sys().(*syscall.Stat_t)
+		if int(st.Uid) != uid || int(st.Gid) != gid {
+			t.Fatalf("%s owned by %d:%d, expected %d:%d", p, st.Uid, st.Gid, uid, gid)
+		}
+	}
+
+	s = newTestServer(t, afero.NewOsFs(), cmder, Credential(1000, 1000), FIFOOwner(-1, 2000))
+	if u, g := s.fifoOwner(); u != -1 || g != 2000 {
+		t.Fatalf("expected FIFOOwner to win, got %d:%d", u, g)
+	}
+}
@@ -0,0 +1,51 @@
+package indiserver
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// readyPollInterval is how often WaitForReady tries to connect to indiserver.
+const readyPollInterval = 50 * time.Millisecond
+
+// WaitForReady waits until the indiserver accepts TCP connections on its port, so drivers
+// can be started and clients connected without sleeping for an arbitrary time. It returns an
+// error if the server isn't ready within timeout or exits first.
+func (s *INDIServer) WaitForReady(timeout time.Duration) error {
+	s.mu.Lock()
+	p, attached := s.proc, s.attached
+	s.mu.Unlock()
+
+	if p == nil && !attached {
+		return ErrNotRunning
+	}
+
+	// An attached server has no process to watch.
+	var exited <-chan struct{}
+	if p != nil {
+		exited = p.done
+	}
+
+	addr := net.JoinHostPort("localhost", s.port)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		conn, err := net.DialTimeout("tcp", addr, readyPollInterval)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			s.log.WithError(err).Warn("indiserver not ready")
+			return fmt.Errorf("indiserver not ready after %s: %v", timeout, err)
+		}
+
+		select {
+		case <-exited:
+			return fmt.Errorf("indiserver exited: %v", p.err)
+		case <-time.After(readyPollInterval):
+		}
+	}
+}
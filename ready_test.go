@@ -0,0 +1,51 @@
+package indiserver
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestWaitForReady(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	l.Close()
+
+	s := newTestServer(t, afero.NewOsFs(), &fakeCommander{})
+	s.port = port
+
+	err = s.WaitForReady(time.Second)
+	if err != ErrNotRunning {
+		t.Fatalf("got %v, expected ErrNotRunning", err)
+	}
+
+	err = s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	// The fake indiserver doesn't listen, so stand in for it once the port check has passed.
+	l, err = net.Listen("tcp", "localhost:"+port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.WaitForReady(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Close()
+
+	err = s.WaitForReady(100 * time.Millisecond)
+	if err == nil {
+		t.Fatal("expected an error once nothing listens on the port")
+	}
+}
@@ -0,0 +1,223 @@
+package indiserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DriverError is an error starting a driver.
+type DriverError struct {
+	Spec DriverSpec
+	Err  error
+}
+
+func (e *DriverError) Error() string {
+	if len(e.Spec.Name) > 0 {
+		return fmt.Sprintf("%s (%s): %s", e.Spec.Driver, e.Spec.Name, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Spec.Driver, e.Err)
+}
+
+func (e *DriverError) Unwrap() error {
+	return e.Err
+}
+
+// DriverErrors holds the errors of every driver that failed to start.
+type DriverErrors []*DriverError
+
+func (e DriverErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// StartDrivers starts every driver in specs with a single FIFO write, so a whole rig comes up
+// as a unit. The result holds a step for every spec. If any spec can't be sent to indiserver,
+// nothing is written: the invalid specs fail and the rest are skipped. Since the commands are
+// written together, every step's Duration is that of the whole batch, including the
+// confirmation window of ConfirmDriverStart. The returned error is the result's Err, or the
+// error writing the FIFO.
+func (s *INDIServer) StartDrivers(specs []DriverSpec) (BulkResult, error) {
+	began := time.Now()
+
+	var b strings.Builder
+
+	result := make(BulkResult, len(specs))
+	invalid := false
+
+	for i, spec := range specs {
+		result[i].Spec = spec
+
+		line, err := spec.startCommand().encode()
+		if err != nil {
+			result[i].Status = StepFailed
+			result[i].Err = err
+			invalid = true
+			continue
+		}
+
+		b.WriteString(line)
+	}
+
+	if invalid {
+		for i := range result {
+			if result[i].Status != StepFailed {
+				result[i].Status = StepSkipped
+			}
+
+			result[i].Duration = time.Since(began)
+		}
+
+		err := result.Err()
+		s.log.WithError(err).Warn("error in encode")
+
+		return result, err
+	}
+
+	var lines <-chan string
+	if s.confirmWindow > 0 {
+		var stop func()
+		lines, stop = s.watchOutput()
+		defer stop()
+	}
+
+	s.mu.Lock()
+	err := s.writeFIFO(b.String())
+	if err == nil {
+		s.track(specs...)
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		for i := range result {
+			result[i].Status = StepFailed
+			result[i].Err = err
+			result[i].Duration = time.Since(began)
+		}
+
+		return result, err
+	}
+
+	var confirmed []error
+	if lines != nil {
+		confirmed = s.confirmStart(specs, lines, s.confirmWindow)
+	}
+
+	for i := range result {
+		result[i].Duration = time.Since(began)
+
+		if confirmed != nil && confirmed[i] != nil {
+			result[i].Status = StepFailed
+			result[i].Err = confirmed[i]
+
+			s.mu.Lock()
+			s.untrack(specs[i])
+			s.mu.Unlock()
+		}
+	}
+
+	return result, result.Err()
+}
+
+// RestartServer stops the indiserver, starts a fresh one and starts every driver that was
+// running on it again.
+func (s *INDIServer) RestartServer() error {
+	return s.RestartServerContext(context.Background())
+}
+
+// RestartServerContext restarts the indiserver like RestartServer, using ctx for both stopping
+// and starting it. If the server restarts but some drivers fail to start, a DriverErrors
+// listing them is returned.
+func (s *INDIServer) RestartServerContext(ctx context.Context) error {
+	s.mu.Lock()
+	attached := s.attached
+	specs := s.startedSpecs()
+	s.mu.Unlock()
+
+	if attached {
+		return errors.New("can't restart an attached indiserver")
+	}
+
+	err := s.StopServerContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.StartServerContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs DriverErrors
+
+	for _, spec := range specs {
+		err = s.StartDriverSpec(spec)
+		if err != nil {
+			s.log.WithError(err).WithField("driver", spec.Driver).Warn("error restarting driver")
+			errs = append(errs, &DriverError{Spec: spec, Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// RestartDriver stops a driver on the indiserver and starts it again with the same config
+// file, skeleton file and prefix it was started with. If name is empty, every device started
+// from the driver is restarted. The restart of a named device is verified by asking
+// indiserver for the device's properties until it defines them, or the DriverTimeout passes.
+// A driver started without a name can't be told apart from other devices, so its restart is
+// instead verified by watching indiserver's output for the DriverTimeout, like
+// ConfirmDriverStart.
+func (s *INDIServer) RestartDriver(driver, name string) error {
+	s.mu.Lock()
+	specs := []DriverSpec{}
+	for _, spec := range s.started {
+		if spec.Driver == driver && (len(name) == 0 || spec.Name == name) {
+			specs = append(specs, spec.DriverSpec)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(specs) == 0 {
+		specs = append(specs, DriverSpec{Driver: driver, Name: name})
+	}
+
+	err := s.StopDriver(driver, name)
+	if err != nil {
+		return err
+	}
+
+	var errs DriverErrors
+
+	for _, spec := range specs {
+		if len(spec.Name) > 0 {
+			err = s.StartDriverSpec(spec)
+			if err == nil {
+				err = s.probeDevice(spec.Name, s.driverTimeout)
+			}
+		} else {
+			err = s.startDriver(spec, s.driverTimeout)
+		}
+
+		if err != nil {
+			errs = append(errs, &DriverError{Spec: spec, Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
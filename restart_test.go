@@ -0,0 +1,312 @@
+package indiserver
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestRestartServer(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, StopTimeout(0))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	first := cmder.last()
+
+	_, err = s.StartDriver("indi_asi_ccd", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.StartDriverSpec(DriverSpec{Driver: "indi_eqmod_telescope", Name: "EQMod Mount", Config: "/etc/eqmod.xml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, first, "start indi_asi_ccd")
+	expectLine(t, first, `start indi_eqmod_telescope -n "EQMod Mount" -c "/etc/eqmod.xml"`)
+
+	err = s.RestartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second := cmder.last()
+	if second == first {
+		t.Fatal("expected a new indiserver process")
+	}
+
+	select {
+	case <-first.exited:
+	default:
+		t.Fatal("expected the old indiserver to have exited")
+	}
+
+	expectLine(t, second, "start indi_asi_ccd")
+	expectLine(t, second, `start indi_eqmod_telescope -n "EQMod Mount" -c "/etc/eqmod.xml"`)
+
+	if len(s.Status().Drivers) != 2 {
+		t.Fatalf("expected 2 drivers, got %+v", s.Status().Drivers)
+	}
+}
+
+func TestRestartServerStartError(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, StopTimeout(0))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmder.set(func(c *fakeCommander) {
+		c.startErr = errors.New("no indiserver")
+	})
+
+	err = s.RestartServer()
+	if err == nil || err.Error() != "no indiserver" {
+		t.Fatalf("expected the start error, got %v", err)
+	}
+
+	if s.Status().Running {
+		t.Fatal("expected the server to be stopped")
+	}
+}
+
+func TestDriverErrors(t *testing.T) {
+	err := DriverErrors{
+		{Spec: DriverSpec{Driver: "indi_asi_ccd"}, Err: ErrNotRunning},
+		{Spec: DriverSpec{Driver: "indi_eqmod_telescope", Name: "EQMod Mount"}, Err: errors.New("boom")},
+	}
+
+	expected := "indi_asi_ccd: indiserver is not running; indi_eqmod_telescope (EQMod Mount): boom"
+	if err.Error() != expected {
+		t.Fatalf("got %q", err.Error())
+	}
+
+	if !errors.Is(err[0], ErrNotRunning) {
+		t.Fatal("expected DriverError to unwrap")
+	}
+}
+
+// listenINDI stands in for indiserver's client port, answering getProperties with a property
+// of each of devices.
+func listenINDI(t *testing.T, port string, devices ...string) net.Listener {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "localhost:"+port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				bufio.NewReader(conn).ReadString('\n')
+
+				for _, device := range devices {
+					fmt.Fprintf(conn, `<defTextVector device="%s" name="DRIVER_INFO"><defText name="DRIVER_NAME">x</defText></defTextVector>`, device)
+				}
+
+				// Keep the connection open like indiserver.
+				time.Sleep(time.Second)
+			}()
+		}
+	}()
+
+	return l
+}
+
+func TestRestartDriver(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, StopTimeout(0), DriverTimeout(300*time.Millisecond))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	l := listenINDI(t, s.port, "ZWO CCD")
+	defer l.Close()
+
+	cmd := cmder.last()
+
+	err = s.StartDriverSpec(DriverSpec{Driver: "indi_asi_ccd", Name: "ZWO CCD", Config: "/etc/asi.xml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmd, `start indi_asi_ccd -n "ZWO CCD" -c "/etc/asi.xml"`)
+
+	err = s.RestartDriver("indi_asi_ccd", "ZWO CCD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmd, `stop indi_asi_ccd -n "ZWO CCD"`)
+	expectLine(t, cmd, `start indi_asi_ccd -n "ZWO CCD" -c "/etc/asi.xml"`)
+
+	if len(s.Status().Drivers) != 1 {
+		t.Fatalf("expected the driver to be tracked once, got %+v", s.Status().Drivers)
+	}
+
+	_, err = s.StartDriver("indi_eqmod_telescope", "EQMod Mount")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.RestartDriver("indi_eqmod_telescope", "EQMod Mount")
+
+	var errs DriverErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Spec.Name != "EQMod Mount" {
+		t.Fatalf("expected the mount not to come back, got %v", err)
+	}
+}
+
+func TestStartDrivers(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, StopTimeout(0))
+
+	specs := []DriverSpec{
+		{Driver: "indi_eqmod_telescope", Name: "EQMod Mount"},
+		{Driver: "indi_asi_ccd"},
+		{Driver: "indi_moonlite_focus", Config: "/etc/moonlite.xml"},
+	}
+
+	result, err := s.StartDrivers(specs)
+	if err != ErrNotRunning {
+		t.Fatalf("got %v, expected ErrNotRunning", err)
+	}
+
+	if len(result) != 3 || result[0].Status != StepFailed || result[0].Err != ErrNotRunning {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	err = s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	cmd := cmder.last()
+
+	invalid := DriverSpec{Driver: "indi_asi_wheel", Name: "EFW\n"}
+
+	result, err = s.StartDrivers([]DriverSpec{specs[0], invalid, specs[1]})
+
+	var errs DriverErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Spec != invalid || !errors.Is(errs[0], ErrInvalidDriverName) {
+		t.Fatalf("expected the invalid spec to be reported, got %v", err)
+	}
+
+	statuses := []StepStatus{}
+	for _, step := range result {
+		statuses = append(statuses, step.Status)
+	}
+
+	if !reflect.DeepEqual(statuses, []StepStatus{StepSkipped, StepFailed, StepSkipped}) {
+		t.Fatalf("unexpected statuses %v", statuses)
+	}
+
+	if !reflect.DeepEqual(result.Failed(), []DriverSpec{specs[0], invalid, specs[1]}) {
+		t.Fatalf("unexpected failed specs %+v", result.Failed())
+	}
+
+	select {
+	case line := <-cmd.lines:
+		t.Fatalf("expected nothing from the rejected batch to be written, got %q", line)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	result, err = s.StartDrivers(specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Failed()) != 0 || result.Err() != nil {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	expectLine(t, cmd, `start indi_eqmod_telescope -n "EQMod Mount"`)
+	expectLine(t, cmd, "start indi_asi_ccd")
+	expectLine(t, cmd, `start indi_moonlite_focus -c "/etc/moonlite.xml"`)
+
+	if !reflect.DeepEqual(s.Status().Drivers, specs) {
+		t.Fatalf("unexpected drivers %+v", s.Status().Drivers)
+	}
+}
+
+func TestRestartDriverWithoutName(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, StopTimeout(0), DriverTimeout(100*time.Millisecond))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	// Another device answering getProperties must not count as the restarted driver.
+	l := listenINDI(t, s.port, "ZWO CCD")
+	defer l.Close()
+
+	cmd := cmder.last()
+
+	_, err = s.StartDriver("indi_eqmod_telescope", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmd, "start indi_eqmod_telescope")
+
+	go func() {
+		<-cmd.lines
+		<-cmd.lines
+		cmd.stderr <- "2020-01-01T00:00:00: Driver indi_eqmod_telescope: Terminated after #0 restarts."
+	}()
+
+	err = s.RestartDriver("indi_eqmod_telescope", "")
+
+	var errs DriverErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || !errors.Is(errs[0], ErrDriverFailed) {
+		t.Fatalf("expected the telescope to fail, got %v", err)
+	}
+
+	_, err = s.StartDriver("indi_eqmod_telescope", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmd, "start indi_eqmod_telescope")
+
+	err = s.RestartDriver("indi_eqmod_telescope", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, cmd, "stop indi_eqmod_telescope")
+	expectLine(t, cmd, "start indi_eqmod_telescope")
+
+	if s.probeDevice("", time.Second) == nil {
+		t.Fatal("expected probing without a device name to fail")
+	}
+}
@@ -0,0 +1,72 @@
+package indiserver
+
+import "time"
+
+// StepStatus is the outcome of one step of a bulk operation.
+type StepStatus int
+
+const (
+	// StepSucceeded means the step was carried out.
+	StepSucceeded StepStatus = iota
+
+	// StepFailed means the step was attempted, or checked, and failed. See StepResult.Err.
+	StepFailed
+
+	// StepSkipped means the step wasn't attempted because another step failed.
+	StepSkipped
+)
+
+func (s StepStatus) String() string {
+	switch s {
+	case StepSucceeded:
+		return "succeeded"
+	case StepFailed:
+		return "failed"
+	case StepSkipped:
+		return "skipped"
+	}
+
+	return "unknown"
+}
+
+// StepResult is the result of starting one driver in a bulk operation.
+type StepResult struct {
+	Spec     DriverSpec
+	Status   StepStatus
+	Duration time.Duration
+	Err      error
+}
+
+// BulkResult holds the result of every step of a bulk operation, in order, so a UI can show
+// which step failed and retry just that one.
+type BulkResult []StepResult
+
+// Failed returns the specs of the steps that failed or were skipped, ready to be retried.
+func (r BulkResult) Failed() []DriverSpec {
+	specs := []DriverSpec{}
+
+	for _, step := range r {
+		if step.Status != StepSucceeded {
+			specs = append(specs, step.Spec)
+		}
+	}
+
+	return specs
+}
+
+// Err returns a DriverErrors holding the error of every failed step, or nil if none failed.
+func (r BulkResult) Err() error {
+	var errs DriverErrors
+
+	for _, step := range r {
+		if step.Status == StepFailed {
+			errs = append(errs, &DriverError{Spec: step.Spec, Err: step.Err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
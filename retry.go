@@ -0,0 +1,45 @@
+package indiserver
+
+import (
+	"time"
+)
+
+// RetryPolicy controls how long to wait between attempts to recover from a failure. The
+// delay starts at InitialDelay and is multiplied by Multiplier after every failed attempt,
+// up to MaxDelay.
+type RetryPolicy struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+
+	// MaxRetries is the number of attempts before giving up. Zero means never give up.
+	MaxRetries int
+}
+
+// DefaultRetryPolicy starts retrying after a second, doubling the delay up to a minute, and
+// never gives up.
+var DefaultRetryPolicy = RetryPolicy{
+	InitialDelay: time.Second,
+	MaxDelay:     time.Minute,
+	Multiplier:   2,
+}
+
+// delay returns how long to wait before the given attempt, counting from zero.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := float64(p.InitialDelay)
+
+	for i := 0; i < attempt && d < float64(p.MaxDelay); i++ {
+		d *= p.Multiplier
+	}
+
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+
+	return time.Duration(d)
+}
+
+// exhausted reports whether attempt is past the number of retries allowed.
+func (p RetryPolicy) exhausted(attempt int) bool {
+	return p.MaxRetries > 0 && attempt >= p.MaxRetries
+}
@@ -0,0 +1,26 @@
+package indiserver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{InitialDelay: time.Second, MaxDelay: 10 * time.Second, Multiplier: 2, MaxRetries: 3}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for attempt, d := range expected {
+		actual := p.delay(attempt)
+		if actual != d {
+			t.Errorf("attempt %d: got %s, expected %s", attempt, actual, d)
+		}
+	}
+
+	if p.exhausted(2) || !p.exhausted(3) {
+		t.Error("expected to give up after 3 attempts")
+	}
+
+	if DefaultRetryPolicy.exhausted(1000) {
+		t.Error("expected the default policy to never give up")
+	}
+}
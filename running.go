@@ -0,0 +1,41 @@
+package indiserver
+
+import "time"
+
+// RunningDriver is a driver started through the INDIServer and not stopped since.
+type RunningDriver struct {
+	DriverSpec
+
+	// Started is when the driver was last started, including by a restart of the server.
+	Started time.Time
+}
+
+// RunningDrivers returns every driver started through the INDIServer and not stopped since, in
+// the order they were started. Drivers that indiserver failed to start, or that crashed, are
+// still included; indiserver doesn't report them back.
+func (s *INDIServer) RunningDrivers() []RunningDriver {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]RunningDriver{}, s.started...)
+}
+
+// track records specs as started now. The caller must hold s.mu.
+func (s *INDIServer) track(specs ...DriverSpec) {
+	now := time.Now()
+
+	for _, spec := range specs {
+		s.started = append(s.started, RunningDriver{DriverSpec: spec, Started: now})
+	}
+}
+
+// startedSpecs returns the specs of the running drivers. The caller must hold s.mu.
+func (s *INDIServer) startedSpecs() []DriverSpec {
+	specs := make([]DriverSpec, 0, len(s.started))
+
+	for _, d := range s.started {
+		specs = append(specs, d.DriverSpec)
+	}
+
+	return specs
+}
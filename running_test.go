@@ -0,0 +1,59 @@
+package indiserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestRunningDrivers(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, StopTimeout(0))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	before := time.Now()
+
+	_, err = s.StartDriver("indi_asi_ccd", "ZWO CCD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.StartDriver("indi_eqmod_telescope", "EQMod Mount")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	running := s.RunningDrivers()
+	if len(running) != 2 || running[0].Driver != "indi_asi_ccd" || running[0].Name != "ZWO CCD" || running[1].Name != "EQMod Mount" {
+		t.Fatalf("unexpected drivers %+v", running)
+	}
+
+	if running[0].Started.Before(before) || running[1].Started.Before(running[0].Started) {
+		t.Fatalf("unexpected start times %+v", running)
+	}
+
+	err = s.StopDriver("indi_asi_ccd", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	running = s.RunningDrivers()
+	if len(running) != 1 || running[0].Driver != "indi_eqmod_telescope" {
+		t.Fatalf("unexpected drivers %+v", running)
+	}
+
+	err = s.StopServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.RunningDrivers()) != 0 {
+		t.Fatalf("expected no drivers after stopping the server, got %+v", s.RunningDrivers())
+	}
+}
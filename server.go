@@ -2,12 +2,19 @@
 package indiserver
 
 import (
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/rickbassham/goexec"
 	"github.com/rickbassham/logging"
@@ -21,7 +28,7 @@ type Commander interface {
 
 // NewINDIServer creates a struct that can be used to get info about installed INDI drivers
 // and start/stop a local indiserver.
-func NewINDIServer(log logging.Logger, fs afero.Fs, port string, cmder Commander) *INDIServer {
+func NewINDIServer(log logging.Logger, fs afero.Fs, port string, cmder Commander, opts ...Option) *INDIServer {
 	if len(port) == 0 {
 		port = "7624"
 	}
@@ -31,6 +38,27 @@ func NewINDIServer(log logging.Logger, fs afero.Fs, port string, cmder Commander
 		fs:    fs,
 		port:  port,
 		cmder: cmder,
+
+		fifoUID: -1,
+		fifoGID: -1,
+
+		binary:      DefaultBinary,
+		verbosity:   1,
+		maxClientMB: -1,
+		maxStreamMB: -1,
+
+		driverRestarts: -1,
+		stopTimeout:    DefaultStopTimeout,
+		driverTimeout:  DefaultDriverTimeout,
+
+		done: make(chan struct{}),
+	}
+
+	// Not started yet, so not running either.
+	close(s.done)
+
+	for _, opt := range opts {
+		opt(s)
 	}
 
 	s.findDrivers()
@@ -43,6 +71,19 @@ type Driver struct {
 	Label   string
 	Driver  string
 	Version string
+
+	// Skeleton is the full path of the driver's skeleton file, if it has one.
+	Skeleton string
+}
+
+// Spec returns a DriverSpec that starts the driver with its skeleton file and its label as the
+// device name, like the INDI Web Manager does.
+func (d Driver) Spec() DriverSpec {
+	return DriverSpec{
+		Driver:   d.Driver,
+		Name:     d.Label,
+		Skeleton: d.Skeleton,
+	}
 }
 
 type device struct {
@@ -65,6 +106,24 @@ type driversList struct {
 	DevGroups []devGroup `xml:"devGroup"`
 }
 
+// fifoPollInterval is how often StartServer checks whether indiserver has opened its FIFO.
+const fifoPollInterval = 10 * time.Millisecond
+
+// errStarting is returned by StartServer while another call is still starting the server.
+var errStarting = errors.New("indiserver is already starting")
+
+// ErrNotRunning is returned when sending a command to an indiserver that was never started
+// or has been stopped.
+var ErrNotRunning = errors.New("indiserver is not running")
+
+// process is a running indiserver.
+type process struct {
+	cmd     goexec.Command
+	started time.Time
+	done    chan struct{} // closed once the process has exited
+	err     error         // the result of cmd.Wait, set before done is closed
+}
+
 // INDIServer is a struct to start/stop and control a local indiserver executable.
 type INDIServer struct {
 	log   logging.Logger
@@ -72,11 +131,55 @@ type INDIServer struct {
 	port  string
 	cmder Commander
 
+	fifoMode os.FileMode
+	fifoUID  int
+	fifoGID  int
+
+	binary      string
+	extraArgs   []string
+	verbosity   int
+	maxClientMB int
+	maxStreamMB int
+	logDir      string
+
+	driverRestarts int
+
+	env        []string
+	credential *syscall.Credential
+	nice       *int
+	rlimits    []rlimit
+
+	stopTimeout   time.Duration
+	driverTimeout time.Duration
+	confirmWindow time.Duration
+	processGroup  bool
+
+	sdNotify bool
+
+	supervise bool
+	retry     RetryPolicy
+
+	mu       sync.Mutex
 	fifoPath string
 	fifo     io.WriteCloser
-	cmd      goexec.Command
+	proc     *process
+	attached bool
+	started  []RunningDriver
+
+	cancelStart context.CancelFunc // cancels a StartServer in progress
+	startDone   chan struct{}      // closed when that StartServer returns
+
+	stopSupervision context.CancelFunc
+	supervisorDone  chan struct{}
+	maintenance     chan struct{} // closed when maintenance mode ends
+
+	done    chan struct{}
+	exitErr error
 
 	drivers map[string][]Driver
+
+	outputMu       sync.Mutex
+	outputWatchers map[chan string]bool
 }
 
 func (s *INDIServer) findDrivers() {
@@ -110,10 +213,17 @@ func (s *INDIServer) findDrivers() {
 			}
 
 			for _, d := range dg.Devices {
+				skel := d.SkeletonFile
+				if len(skel) > 0 && !path.IsAbs(skel) {
+					// Skeleton files are installed next to the driver list.
+					skel = path.Join(path.Dir(fp), skel)
+				}
+
 				list = append(list, Driver{
-					Driver:  d.Driver,
-					Version: d.Version,
-					Label:   d.Label,
+					Driver:   d.Driver,
+					Version:  d.Version,
+					Label:    d.Label,
+					Skeleton: skel,
 				})
 			}
 
@@ -127,12 +237,181 @@ func (s *INDIServer) Drivers() map[string][]Driver {
 	return s.drivers
 }
 
-// StartServer starts up the indiserver. Be sure to call StopServer when you are done!
+// StartServer starts up the indiserver. Be sure to call StopServer when you are done! If the
+// port is already bound, an error matching ErrPortInUse is returned without starting it.
 func (s *INDIServer) StartServer() error {
-	if s.cmd != nil {
+	return s.StartServerContext(context.Background())
+}
+
+// StartServerContext starts up the indiserver like StartServer. If ctx is done before the
+// indiserver has opened its FIFO, the process is killed and ctx.Err() is returned. The same
+// happens, with context.Canceled, if StopServer is called while it is starting.
+func (s *INDIServer) StartServerContext(ctx context.Context) error {
+	s.mu.Lock()
+
+	if s.proc != nil || s.attached {
+		s.mu.Unlock()
 		return nil
 	}
 
+	if s.startDone != nil {
+		s.mu.Unlock()
+		return errStarting
+	}
+
+	err := s.checkPort()
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	err = s.createFIFO()
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	s.cancelStart, s.startDone = cancel, done
+	s.mu.Unlock()
+
+	// Launch without holding the lock, so an indiserver that is slow to open its FIFO
+	// doesn't block Status or StopServer.
+	p, fifo, err := s.launch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cancelStart, s.startDone = nil, nil
+	close(done)
+
+	if err == nil && ctx.Err() != nil {
+		// StopServer was called while starting.
+		s.discard(p, fifo)
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		s.removeFIFO()
+		return err
+	}
+
+	s.proc = p
+	s.fifo = fifo
+	s.done = make(chan struct{})
+	s.exitErr = nil
+
+	s.notify("READY=1")
+
+	if s.supervise {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopSupervision = cancel
+		s.supervisorDone = make(chan struct{})
+
+		go s.superviseProcess(ctx, p, s.supervisorDone)
+	} else {
+		go s.watchProcess(p)
+	}
+
+	return nil
+}
+
+// watchProcess releases an unsupervised process that exits on its own and reports it
+// through Done.
+func (s *INDIServer) watchProcess(p *process) {
+	<-p.done
+
+	if s.processGroup {
+		// StopServer holds the lock while it stops the group itself.
+		s.mu.Lock()
+		current := s.proc == p
+		s.mu.Unlock()
+
+		if current {
+			s.stopGroup(context.Background(), p)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.release(p)
+}
+
+// release cleans up after p exited for good, so the next StartServer starts a new server,
+// and closes Done with its exit error. It does nothing if p is no longer the current process,
+// e.g. because StopServer has already taken care of it. The caller must hold s.mu.
+func (s *INDIServer) release(p *process) {
+	if s.proc != p {
+		return
+	}
+
+	s.log.WithError(p.err).Warn("indiserver exited")
+
+	err := s.fifo.Close()
+	if err != nil {
+		s.log.WithError(err).Warn("error in s.fifo.Close")
+	}
+
+	s.removeFIFO()
+
+	s.proc = nil
+	s.fifo = nil
+	s.started = nil
+	s.finish(p.err)
+}
+
+// finish closes the Done channel of the current run with err as its ExitError. The caller
+// must hold s.mu.
+func (s *INDIServer) finish(err error) {
+	select {
+	case <-s.done:
+	default:
+		s.exitErr = err
+		close(s.done)
+	}
+}
+
+// Done returns a channel that is closed once the indiserver is no longer running and won't
+// be restarted: after StopServer, when it exits on its own without Supervise, or when
+// supervision gives up. Before StartServer it returns a closed channel. Each StartServer
+// begins a new run with a new channel.
+func (s *INDIServer) Done() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.done
+}
+
+// ExitError returns why the indiserver stopped once Done is closed: the process's exit
+// error if it exited on its own, or nil after StopServer.
+func (s *INDIServer) ExitError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.exitErr
+}
+
+// launch starts an indiserver on the existing FIFO and opens the FIFO for writing.
+func (s *INDIServer) launch(ctx context.Context) (*process, io.WriteCloser, error) {
+	p, err := s.startProcess()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fifo, err := s.openFIFO(ctx, p)
+	if err != nil {
+		s.killProcess(p)
+		return nil, nil, err
+	}
+
+	return p, fifo, nil
+}
+
+func (s *INDIServer) createFIFO() error {
 	dir, err := afero.TempDir(s.fs, "", "")
 	if err != nil {
 		s.log.WithError(err).Warn("error in afero.TempDir")
@@ -141,108 +420,482 @@ func (s *INDIServer) StartServer() error {
 
 	s.fifoPath = fmt.Sprintf("%s/fifo", dir)
 
-	err = syscall.Mkfifo(s.fifoPath, 0666)
+	mode := os.FileMode(0666)
+	if s.fifoMode != 0 {
+		mode = s.fifoMode
+	}
+
+	err = syscall.Mkfifo(s.fifoPath, uint32(mode))
 	if err != nil {
 		s.log.WithError(err).Warn("error in syscall.Mkfifo")
+		s.removeFIFODir(dir)
 		return err
 	}
 
-	s.cmd = s.cmder.Command("/usr/bin/indiserver", "-v", "-f", s.fifoPath, "-p", s.port)
-
-	stdout, err := s.cmd.Stdout()
+	err = s.setFIFOPermissions(dir)
 	if err != nil {
-		s.log.WithError(err).Warn("error in s.cmd.Stdout")
+		s.removeFIFODir(dir)
 		return err
 	}
 
-	stderr, err := s.cmd.Stderr()
+	return nil
+}
+
+func (s *INDIServer) removeFIFO() {
+	s.removeFIFODir(path.Dir(s.fifoPath))
+}
+
+func (s *INDIServer) removeFIFODir(dir string) {
+	err := s.fs.RemoveAll(dir)
 	if err != nil {
-		s.log.WithError(err).Warn("error in s.cmd.Stderr")
-		return err
+		s.log.WithError(err).Warn("error in s.fs.RemoveAll")
 	}
+}
 
-	go func() {
-		for line := range stdout {
-			s.log.WithField("line", line).Info("from indiserver")
+func (s *INDIServer) setFIFOPermissions(dir string) error {
+	if s.fifoMode != 0 {
+		// Mkfifo is subject to the umask, so set the exact mode afterwards.
+		err := s.fs.Chmod(s.fifoPath, s.fifoMode)
+		if err != nil {
+			s.log.WithError(err).Warn("error in s.fs.Chmod")
+			return err
 		}
-	}()
 
-	go func() {
-		for line := range stderr {
-			s.log.WithField("line", line).Info("from indiserver")
+		// Let anyone who may use the FIFO get to it through the temp dir.
+		dirMode := os.FileMode(0700)
+		if s.fifoMode&0060 != 0 {
+			dirMode |= 0010
+		}
+		if s.fifoMode&0006 != 0 {
+			dirMode |= 0001
 		}
+
+		err = s.fs.Chmod(dir, dirMode)
+		if err != nil {
+			s.log.WithError(err).Warn("error in s.fs.Chmod")
+			return err
+		}
+	}
+
+	uid, gid := s.fifoOwner()
+	if uid != -1 || gid != -1 {
+		for _, p := range []string{dir, s.fifoPath} {
+			err := os.Chown(p, uid, gid)
+			if err != nil {
+				s.log.WithError(err).Warn("error in os.Chown")
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fifoOwner returns the user and group to give the FIFO and its directory, or -1 to leave
+// them unchanged. Without FIFOOwner or FIFOMode, an indiserver run with Credential gets to
+// own them, since it couldn't get into the private temp dir otherwise.
+func (s *INDIServer) fifoOwner() (int, int) {
+	if s.fifoUID == -1 && s.fifoGID == -1 && s.fifoMode == 0 && s.credential != nil {
+		return int(s.credential.Uid), int(s.credential.Gid)
+	}
+
+	return s.fifoUID, s.fifoGID
+}
+
+// resolveBinary returns the indiserver binary to run, looking it up in $PATH if it is a bare
+// name.
+func (s *INDIServer) resolveBinary() (string, error) {
+	if strings.Contains(s.binary, "/") {
+		return s.binary, nil
+	}
+
+	binary, err := exec.LookPath(s.binary)
+	if err != nil {
+		s.log.WithError(err).Warn("error in exec.LookPath")
+		return "", err
+	}
+
+	return binary, nil
+}
+
+// args returns the indiserver command line arguments.
+func (s *INDIServer) args() []string {
+	args := []string{}
+
+	if s.verbosity > 0 {
+		args = append(args, "-"+strings.Repeat("v", s.verbosity))
+	}
+
+	args = append(args, "-f", s.fifoPath, "-p", s.port)
+
+	if s.maxClientMB >= 0 {
+		args = append(args, "-m", strconv.Itoa(s.maxClientMB))
+	}
+
+	if s.maxStreamMB >= 0 {
+		args = append(args, "-d", strconv.Itoa(s.maxStreamMB))
+	}
+
+	if len(s.logDir) > 0 {
+		args = append(args, "-l", s.logDir)
+	}
+
+	if s.driverRestarts >= 0 {
+		args = append(args, "-r", strconv.Itoa(s.driverRestarts))
+	}
+
+	return append(args, s.extraArgs...)
+}
+
+func (s *INDIServer) startProcess() (*process, error) {
+	binary, err := s.resolveBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := s.cmder.Command(binary, s.args()...)
+
+	err = s.configureProcess(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.Stdout()
+	if err != nil {
+		s.log.WithError(err).Warn("error in cmd.Stdout")
+		return nil, err
+	}
+
+	stderr, err := cmd.Stderr()
+	if err != nil {
+		s.log.WithError(err).Warn("error in cmd.Stderr")
+		return nil, err
+	}
+
+	var output sync.WaitGroup
+	output.Add(2)
+
+	for _, lines := range []<-chan string{stdout, stderr} {
+		go func(lines <-chan string) {
+			defer output.Done()
+
+			for line := range lines {
+				s.handleOutput(line)
+			}
+		}(lines)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		s.log.WithError(err).Warn("error in cmd.Start")
+		return nil, err
+	}
+
+	p := &process{
+		cmd:     cmd,
+		started: time.Now(),
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		// Wait closes the output pipes, so let the readers get everything the process
+		// printed before it exited first.
+		output.Wait()
+
+		p.err = cmd.Wait()
+		close(p.done)
 	}()
 
-	err = s.cmd.Start()
+	err = s.applyLimits(cmd)
+	if err != nil {
+		s.killProcess(p)
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// openFIFO opens the write end of the FIFO once indiserver has opened the read end. It
+// fails if ctx is done or the process exits first.
+func (s *INDIServer) openFIFO(ctx context.Context, p *process) (io.WriteCloser, error) {
+	ticker := time.NewTicker(fifoPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// A non-blocking open fails with ENXIO until there is a reader, which lets us
+		// give up instead of blocking forever on a server that never opens the FIFO.
+		f, err := s.fs.OpenFile(s.fifoPath, os.O_WRONLY|syscall.O_NONBLOCK, os.ModeNamedPipe)
+		if err == nil {
+			return f, nil
+		}
+
+		if !errors.Is(err, syscall.ENXIO) {
+			s.log.WithError(err).Warn("error in s.fs.OpenFile")
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-p.done:
+			return nil, fmt.Errorf("indiserver exited before opening the fifo: %v", p.err)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *INDIServer) killProcess(p *process) {
+	err := s.kill(p.cmd)
+	if err != nil {
+		s.log.WithError(err).Warn("error in s.kill")
+		return
+	}
+
+	<-p.done
+}
+
+// StopServer stops the currently running indiserver and cleans up. If indiserver has already
+// exited on its own, it has been cleaned up then and its exit error is left in ExitError.
+func (s *INDIServer) StopServer() error {
+	return s.StopServerContext(context.Background())
+}
+
+// StopServerContext stops the currently running indiserver like StopServer. If ctx is done
+// while waiting for the process to exit after SIGTERM, it is killed right away. If ctx is
+// done before the killed process has exited, ctx.Err() is returned and the server is left as
+// it is, so the stop can be retried; the FIFO is only cleaned up once the process is gone.
+// With ProcessGroup, killing indiserver kills its drivers too. A StartServer still in
+// progress is cancelled.
+func (s *INDIServer) StopServerContext(ctx context.Context) error {
+	err := s.endSupervision(ctx)
 	if err != nil {
-		s.log.WithError(err).Warn("error in s.cmd.Start")
 		return err
 	}
 
-	s.fifo, err = s.fs.OpenFile(s.fifoPath, os.O_WRONLY, os.ModeNamedPipe)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.attached {
+		s.detach()
+		return nil
+	}
+
+	if s.startDone != nil {
+		return s.cancelStarting(ctx)
+	}
+
+	if s.proc == nil {
+		return nil
+	}
+
+	p := s.proc
+
+	s.notify("STOPPING=1")
+
+	err = s.terminate(ctx, p)
 	if err != nil {
-		s.log.WithError(err).Warn("error in s.fs.OpenFile")
 		return err
 	}
 
+	s.proc = nil
+	s.started = nil
+	s.finish(nil)
+
+	err = s.fifo.Close()
+	if err != nil {
+		s.log.WithError(err).Warn("error in s.fifo.Close")
+	}
+
+	s.fifo = nil
+
+	s.removeFIFO()
+
+	if p.err != nil {
+		if p.err.Error() == "signal: killed" || p.err.Error() == "signal: terminated" {
+			// We just stopped it. It's not an error.
+			return nil
+		}
+
+		s.log.WithError(p.err).Warn("error in p.cmd.Wait")
+		return p.err
+	}
+
 	return nil
 }
 
-// StopServer stops the currently running indiserver and cleans up.
-func (s *INDIServer) StopServer() error {
-	if s.cmd == nil {
+// cancelStarting makes a StartServer in progress fail and waits for it to clean up. The
+// caller must hold s.mu, which is released while waiting.
+func (s *INDIServer) cancelStarting(ctx context.Context) error {
+	// Cancel while holding the lock, so the start can't publish its server first.
+	s.cancelStart()
+	done := s.startDone
+
+	s.mu.Unlock()
+	defer s.mu.Lock()
+
+	select {
+	case <-done:
 		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// terminate asks p to exit with SIGTERM, then kills it if it is still running after the stop
+// timeout, and waits for it to exit.
+func (s *INDIServer) terminate(ctx context.Context, p *process) error {
+	if s.processGroup {
+		return s.stopGroup(ctx, p)
 	}
 
-	defer func() {
-		s.cmd = nil
+	select {
+	case <-p.done:
+		// It already exited on its own; there is nothing to stop.
+		return nil
+	default:
+	}
 
-		err := s.fs.RemoveAll(path.Dir(s.fifoPath))
+	if s.stopTimeout > 0 {
+		err := p.cmd.Signal(syscall.SIGTERM)
 		if err != nil {
-			s.log.WithError(err).Warn("error in s.fs.RemoveAll")
+			s.log.WithError(err).Warn("error in p.cmd.Signal")
+		} else {
+			timer := time.NewTimer(s.stopTimeout)
+			defer timer.Stop()
+
+			select {
+			case <-p.done:
+				return nil
+			case <-timer.C:
+				s.log.WithField("timeout", s.stopTimeout.String()).Warn("indiserver did not exit after SIGTERM, killing it")
+			case <-ctx.Done():
+			}
 		}
-	}()
+	}
 
-	err := s.cmd.Kill()
+	err := s.kill(p.cmd)
 	if err != nil {
-		s.log.WithError(err).Warn("error in s.cmd.Signal")
+		s.log.WithError(err).Warn("error in s.kill")
 		return err
 	}
 
-	err = s.cmd.Wait()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		s.log.WithError(ctx.Err()).Warn("indiserver did not exit")
+		return ctx.Err()
+	}
+}
+
+// StartDriver starts up a driver on the indiserver. Note that unless ConfirmDriverStart is
+// set, this will NOT return an error if the indiserver doesn't recognize the driver or if it
+// has any other issues. Watch the log for info on failures inside indiserver. A driver or
+// name that can't be sent to indiserver, e.g. one containing a double quote or a newline,
+// returns an error matching ErrInvalidDriverName. The returned DriverHandle can be used to
+// manage the driver later.
+func (s *INDIServer) StartDriver(driver, name string) (*DriverHandle, error) {
+	spec := DriverSpec{Driver: driver, Name: name}
+
+	err := s.StartDriverSpec(spec)
 	if err != nil {
-		if err.Error() == "signal: killed" {
-			// We just killed it. It's not an error.
-			return nil
-		}
+		return nil, err
+	}
+
+	return &DriverHandle{s: s, spec: spec}, nil
+}
+
+// StartDriverSpec starts up a driver on the indiserver like StartDriver, also passing the
+// spec's config file, skeleton file and prefix when they are set.
+func (s *INDIServer) StartDriverSpec(spec DriverSpec) error {
+	return s.startDriver(spec, s.confirmWindow)
+}
+
+// startDriver starts spec and, if window isn't zero, confirms the start like
+// ConfirmDriverStart for that long.
+func (s *INDIServer) startDriver(spec DriverSpec, window time.Duration) error {
+	var lines <-chan string
+	if window > 0 {
+		var stop func()
+		lines, stop = s.watchOutput()
+		defer stop()
+	}
+
+	s.mu.Lock()
+	err := s.writeCommand(spec.startCommand())
+	if err == nil {
+		s.track(spec)
+	}
+	s.mu.Unlock()
+
+	if err != nil || lines == nil {
+		return err
+	}
+
+	err = s.confirmStart([]DriverSpec{spec}, lines, window)[0]
+	if err != nil {
+		s.mu.Lock()
+		s.untrack(spec)
+		s.mu.Unlock()
 
-		s.log.WithError(err).Warn("error in s.cmd.Wait")
 		return err
 	}
 
 	return nil
 }
 
-// StartDriver starts up a driver on the indiserver. Note that this will NOT return an
-// error if the indiserver doesn't recognize the driver or if it has any other issues.
-// Watch the log for info on failures inside indiserver.
-func (s *INDIServer) StartDriver(driver, name string) error {
-	cmd := fmt.Sprintf("start %s -n \"%s\"\n", driver, name)
+// StartRemoteDriver chains device from the indiserver on host, so clients of this server can
+// use it too. port may be empty to use the default INDI port. Stop it with StopDriver, passing
+// device@host[:port] as the driver.
+func (s *INDIServer) StartRemoteDriver(device, host, port string) error {
+	driver, err := remoteSpec(device, host, port)
+	if err != nil {
+		return err
+	}
 
-	_, err := s.fifo.Write([]byte(cmd))
+	return s.StartDriverSpec(DriverSpec{Driver: driver})
+}
+
+// StopDriver stops a driver on the indiserver. If name is empty, every device started from
+// the driver is stopped.
+func (s *INDIServer) StopDriver(driver, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.writeCommand(stopCommand(driver).withName(name))
 	if err != nil {
-		s.log.WithError(err).Warn("error in s.fifo.Write")
 		return err
 	}
 
+	started := s.started[:0]
+	for _, spec := range s.started {
+		if spec.Driver != driver || (len(name) > 0 && spec.Name != name) {
+			started = append(started, spec)
+		}
+	}
+	s.started = started
+
 	return nil
 }
 
-// StopDriver stops a driver on the indiserver.
-func (s *INDIServer) StopDriver(driver, name string) error {
-	cmd := fmt.Sprintf("stop %s \"%s\"\n", driver, name)
+// writeCommand writes cmd to the FIFO. The caller must hold s.mu.
+func (s *INDIServer) writeCommand(cmd *fifoCommand) error {
+	line, err := cmd.encode()
+	if err != nil {
+		s.log.WithError(err).Warn("error in cmd.encode")
+		return err
+	}
+
+	return s.writeFIFO(line)
+}
+
+// writeFIFO writes encoded commands to the FIFO in a single write. The caller must hold s.mu.
+func (s *INDIServer) writeFIFO(lines string) error {
+	if s.fifo == nil {
+		return ErrNotRunning
+	}
 
-	_, err := s.fifo.Write([]byte(cmd))
+	_, err := s.fifo.Write([]byte(lines))
 	if err != nil {
 		s.log.WithError(err).Warn("error in s.fifo.Write")
 		return err
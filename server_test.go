@@ -5,7 +5,6 @@ import (
 	"os/signal"
 	"time"
 
-	"github.com/rickbassham/goexec"
 	"github.com/rickbassham/logging"
 	"github.com/spf13/afero"
 	"github.com/goastro/indiserver"
@@ -15,15 +14,20 @@ func Example() {
 	logger := logging.NewLogger(os.Stdout, logging.JSONFormatter{}, logging.LogLevelInfo)
 	fs := afero.NewOsFs()
 	port := ""
-	s := indiserver.NewINDIServer(logger, fs, port, goexec.ExecCommand{})
+	s := indiserver.NewINDIServer(logger, fs, port, indiserver.ExecCommander{})
 
 	logger.WithField("drivers", s.Drivers()).Info("drivers")
 
 	s.StartServer()
 
-	time.Sleep(1 * time.Second)
+	s.WaitForReady(10 * time.Second)
 
-	s.StartDriver("indi_asi_ccd", "CCD 1")
+	ccd, err := s.StartDriver("indi_asi_ccd", "CCD 1")
+	if err != nil {
+		logger.WithError(err).Error("error starting driver")
+		s.StopServer()
+		return
+	}
 
 	println("Server Running. Press CTRL-C to stop.")
 
@@ -32,7 +36,7 @@ func Example() {
 	signal.Notify(sigchan, os.Interrupt)
 	<-sigchan
 
-	s.StopDriver("indi_asi_ccd", "CCD 1")
+	ccd.Stop()
 	time.Sleep(1 * time.Second)
 	s.StopServer()
 }
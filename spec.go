@@ -0,0 +1,195 @@
+package indiserver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DriverSpec describes a driver to start on the indiserver.
+type DriverSpec struct {
+	// Driver is the driver binary (e.g. indi_asi_ccd), or a remote device spec
+	// (device@host[:port]) to chain a device from another indiserver.
+	Driver string
+
+	// Name is the device name. May be empty to use the driver's default.
+	Name string
+
+	// Config is an optional driver config file.
+	Config string
+
+	// Skeleton is an optional driver skeleton file.
+	Skeleton string
+
+	// Prefix is an optional INDIPREFIX for the driver.
+	Prefix string
+}
+
+func (d DriverSpec) startCommand() *fifoCommand {
+	return startCommand(d.Driver).
+		withName(d.Name).
+		withConfig(d.Config).
+		withSkeleton(d.Skeleton).
+		withPrefix(d.Prefix)
+}
+
+// indiserver command line flags that take a value.
+var serverValueFlags = map[string]bool{
+	"-d": true,
+	"-f": true,
+	"-l": true,
+	"-m": true,
+	"-p": true,
+	"-r": true,
+}
+
+// ParseCommandLine parses an indiserver style startup string into driver specs, so startup
+// strings like
+//
+//	indiserver -v -p 7624 indi_eqmod_telescope "indi_asi_ccd -n 'CCD 1'" Focuser@pier:7625
+//
+// can be reused. The leading indiserver binary and its own flags are optional and ignored.
+// A quoted driver may carry -n, -c, -s and -p arguments just like a FIFO start command.
+func ParseCommandLine(line string) ([]DriverSpec, error) {
+	args, err := splitArgs(line)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) > 0 && (args[0] == "indiserver" || strings.HasSuffix(args[0], "/indiserver")) {
+		args = args[1:]
+	}
+
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if serverValueFlags[args[0]] {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("missing value for %s", args[0])
+			}
+
+			args = args[1:]
+		}
+
+		args = args[1:]
+	}
+
+	specs := []DriverSpec{}
+
+	for _, arg := range args {
+		spec, err := parseDriverArg(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
+
+func parseDriverArg(arg string) (DriverSpec, error) {
+	fields, err := splitArgs(arg)
+	if err != nil {
+		return DriverSpec{}, err
+	}
+
+	if len(fields) == 0 {
+		return DriverSpec{}, fmt.Errorf("empty driver")
+	}
+
+	spec := DriverSpec{Driver: fields[0]}
+
+	for i := 1; i < len(fields); i += 2 {
+		if i+1 >= len(fields) {
+			return DriverSpec{}, fmt.Errorf("missing value for %s in %q", fields[i], arg)
+		}
+
+		value := fields[i+1]
+
+		switch fields[i] {
+		case "-n":
+			spec.Name = value
+		case "-c":
+			spec.Config = value
+		case "-s":
+			spec.Skeleton = value
+		case "-p":
+			spec.Prefix = value
+		default:
+			return DriverSpec{}, fmt.Errorf("unknown argument %s in %q", fields[i], arg)
+		}
+	}
+
+	return spec, nil
+}
+
+// splitArgs splits s into words like a POSIX shell would, honoring single quotes, double
+// quotes and backslash escapes. No expansions are performed.
+func splitArgs(s string) ([]string, error) {
+	args := []string{}
+
+	var (
+		b      strings.Builder
+		inWord bool
+		quote  rune
+		escape bool
+	)
+
+	for _, r := range s {
+		switch {
+		case escape:
+			// Inside double quotes a backslash only escapes $ ` " \ and newline.
+			if quote == '"' && !strings.ContainsRune("$`\"\\\n", r) {
+				b.WriteRune('\\')
+			}
+
+			// An escaped newline is a line continuation and is dropped.
+			if r != '\n' {
+				b.WriteRune(r)
+			}
+
+			escape = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				b.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' {
+				escape = true
+			} else {
+				b.WriteRune(r)
+			}
+		case r == '\\':
+			escape = true
+			inWord = true
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				args = append(args, b.String())
+				b.Reset()
+				inWord = false
+			}
+		default:
+			b.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+
+	if escape {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+
+	if inWord {
+		args = append(args, b.String())
+	}
+
+	return args, nil
+}
@@ -0,0 +1,104 @@
+package indiserver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected []DriverSpec
+	}{
+		{"", []DriverSpec{}},
+		{"indi_eqmod_telescope indi_asi_ccd", []DriverSpec{{Driver: "indi_eqmod_telescope"}, {Driver: "indi_asi_ccd"}}},
+		{
+			`/usr/bin/indiserver -v -p 7625 -m 100 indi_eqmod_telescope "indi_asi_ccd -n 'CCD 1' -c /tmp/ccd.xml" Focuser@pier:7625`,
+			[]DriverSpec{
+				{Driver: "indi_eqmod_telescope"},
+				{Driver: "indi_asi_ccd", Name: "CCD 1", Config: "/tmp/ccd.xml"},
+				{Driver: "Focuser@pier:7625"},
+			},
+		},
+		{`indiserver 'indi_simulator_ccd -n "Sim \"A\"" -s /tmp/sk.xml -p /opt'`, []DriverSpec{{Driver: "indi_simulator_ccd", Name: `Sim "A"`, Skeleton: "/tmp/sk.xml", Prefix: "/opt"}}},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseCommandLine(test.line)
+		if err != nil {
+			t.Errorf("%q: %s", test.line, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%q: got %+v, expected %+v", test.line, actual, test.expected)
+		}
+	}
+
+	for _, line := range []string{
+		`indiserver -p`,
+		`indi_asi_ccd "unterminated`,
+		`"indi_asi_ccd -n"`,
+		`"indi_asi_ccd -x foo"`,
+		`indi_asi_ccd \`,
+	} {
+		_, err := ParseCommandLine(line)
+		if err == nil {
+			t.Errorf("%q: expected error", line)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected []string
+	}{
+		{`a "b c" 'd e'`, []string{"a", "b c", "d e"}},
+		{`"C:\x" "a\b"`, []string{`C:\x`, `a\b`}},
+		{`"a\\b" "a\$b" "a\"b" "a\` + "`" + `b"`, []string{`a\b`, `a$b`, `a"b`, "a`b"}},
+		{`'a\b' a\b a\ b`, []string{`a\b`, "ab", "a b"}},
+		{"\"a\\\nb\" c\\\nd", []string{"ab", "cd"}},
+		{`"" ''`, []string{"", ""}},
+	}
+
+	for _, test := range tests {
+		actual, err := splitArgs(test.s)
+		if err != nil {
+			t.Errorf("%q: %s", test.s, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%q: got %q, expected %q", test.s, actual, test.expected)
+		}
+	}
+}
+
+func TestDriverSpecStartCommand(t *testing.T) {
+	spec := DriverSpec{Driver: "indi_asi_ccd", Name: "CCD 1", Config: "/tmp/ccd.xml", Skeleton: "/tmp/sk.xml", Prefix: "/opt/indi"}
+
+	line, err := spec.startCommand().encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "start indi_asi_ccd -n \"CCD 1\" -c \"/tmp/ccd.xml\" -s \"/tmp/sk.xml\" -p \"/opt/indi\"\n"
+	if line != expected {
+		t.Fatalf("got %q, expected %q", line, expected)
+	}
+
+	line, err = stopCommand("indi_asi_ccd").withName("CCD 1").withConfig("/tmp/ccd.xml").encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if line != "stop indi_asi_ccd -n \"CCD 1\"\n" {
+		t.Fatalf("got %q", line)
+	}
+
+	_, err = DriverSpec{Driver: "indi_asi_ccd", Config: "/tmp/\"x\".xml"}.startCommand().encode()
+	if err == nil {
+		t.Fatal("expected error for invalid config")
+	}
+}
@@ -0,0 +1,73 @@
+package indiserver
+
+import (
+	"time"
+)
+
+// Status describes the state of an INDIServer.
+type Status struct {
+	// Running is true while the indiserver process is alive, or while attached to an
+	// external indiserver.
+	Running bool
+
+	// Attached is true while attached to an external indiserver with AttachServer.
+	Attached bool
+
+	// PID is the process ID of the indiserver, or 0 if it is not running or the Commander
+	// doesn't report process IDs (see ExecCommander).
+	PID int
+
+	// Uptime is how long the current indiserver process has been running.
+	Uptime time.Duration
+
+	// Port is the TCP port the indiserver listens on.
+	Port string
+
+	// DriverRestarts is the value set with the DriverRestarts option, or -1 if indiserver's
+	// default is used.
+	DriverRestarts int
+
+	// Maintenance is true while the server is in maintenance mode.
+	Maintenance bool
+
+	// Drivers are the drivers started through the INDIServer and not stopped since.
+	Drivers []DriverSpec
+}
+
+// Status returns the current state of the indiserver.
+func (s *INDIServer) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := Status{
+		Port:           s.port,
+		DriverRestarts: s.driverRestarts,
+		Maintenance:    s.maintenance != nil,
+		Drivers:        s.startedSpecs(),
+	}
+
+	if s.attached {
+		st.Running = true
+		st.Attached = true
+		return st
+	}
+
+	if s.proc == nil {
+		return st
+	}
+
+	select {
+	case <-s.proc.done:
+		return st
+	default:
+	}
+
+	st.Running = true
+	st.Uptime = time.Since(s.proc.started)
+
+	if pc, ok := s.proc.cmd.(pidCommand); ok {
+		st.PID = pc.Pid()
+	}
+
+	return st
+}
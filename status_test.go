@@ -0,0 +1,57 @@
+package indiserver
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestStatus(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder)
+
+	st := s.Status()
+	if st.Running || st.Port != s.port || st.DriverRestarts != -1 || len(st.Drivers) != 0 {
+		t.Fatalf("unexpected status before start: %+v", st)
+	}
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	_, err = s.StartDriver("indi_asi_ccd", "CCD 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st = s.Status()
+	if !st.Running || st.PID != 1234 || st.Uptime <= 0 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+
+	if !reflect.DeepEqual(st.Drivers, []DriverSpec{{Driver: "indi_asi_ccd", Name: "CCD 1"}}) {
+		t.Fatalf("unexpected drivers: %+v", st.Drivers)
+	}
+
+	p := s.proc
+
+	cmder.last().exit(errors.New("exit status 1"))
+	<-p.done
+
+	st = s.Status()
+	if st.Running || st.PID != 0 {
+		t.Fatalf("unexpected status after exit: %+v", st)
+	}
+}
+
+func TestStatusDriverRestarts(t *testing.T) {
+	s := newTestServer(t, afero.NewOsFs(), &fakeCommander{}, DriverRestarts(3))
+
+	if s.Status().DriverRestarts != 3 {
+		t.Fatalf("got %d", s.Status().DriverRestarts)
+	}
+}
@@ -0,0 +1,200 @@
+package indiserver
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Supervise makes the INDIServer restart indiserver whenever it exits without StopServer
+// being called, waiting between attempts according to policy. Every driver started through
+// the INDIServer is started again on the new server. A restarted server that stays up for
+// policy.MaxDelay resets the backoff and retry count; with no MaxDelay they are never reset.
+// Once supervision gives up, the server is released like one that exited without Supervise.
+func Supervise(policy RetryPolicy) Option {
+	return func(s *INDIServer) {
+		s.supervise = true
+		s.retry = policy
+	}
+}
+
+// endSupervision stops the supervisor, if any, and waits for it to finish so it can't
+// restart the server while it is being stopped.
+func (s *INDIServer) endSupervision(ctx context.Context) error {
+	s.mu.Lock()
+	stop, done := s.stopSupervision, s.supervisorDone
+	s.mu.Unlock()
+
+	if stop == nil {
+		return nil
+	}
+
+	stop()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.mu.Lock()
+	s.stopSupervision, s.supervisorDone = nil, nil
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *INDIServer) superviseProcess(ctx context.Context, p *process, done chan struct{}) {
+	defer close(done)
+
+	attempt := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.done:
+		}
+
+		// Without a MaxDelay there is no uptime that proves the server stable; keep counting.
+		if s.retry.MaxDelay > 0 && time.Since(p.started) >= s.retry.MaxDelay {
+			attempt = 0
+		}
+
+		s.log.WithError(p.err).Warn("indiserver exited unexpectedly")
+
+		if s.processGroup {
+			// Don't let orphaned drivers hold on to devices the new server needs.
+			s.stopGroup(ctx, p)
+		}
+
+		if !s.waitForMaintenance(ctx) {
+			return
+		}
+
+		for {
+			if s.retry.exhausted(attempt) {
+				s.log.WithField("attempts", attempt).Error("giving up restarting indiserver")
+
+				s.mu.Lock()
+				s.release(p)
+				s.mu.Unlock()
+
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.retry.delay(attempt)):
+			}
+
+			attempt++
+
+			np, err := s.restart(ctx)
+			if err == nil {
+				p = np
+				break
+			}
+
+			s.log.WithError(err).WithField("attempt", attempt).Warn("error in s.restart")
+		}
+	}
+}
+
+// waitForMaintenance blocks while the server is in maintenance mode. It returns false if
+// ctx is done first.
+func (s *INDIServer) waitForMaintenance(ctx context.Context) bool {
+	s.mu.Lock()
+	maintenance := s.maintenance
+	s.mu.Unlock()
+
+	if maintenance == nil {
+		return true
+	}
+
+	s.log.Info("not restarting indiserver during maintenance")
+
+	select {
+	case <-maintenance:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// EnterMaintenanceMode suspends automatic restarts, so manual hardware work such as
+// swapping cameras isn't fought by Supervise. Status reports the mode while it lasts. If
+// indiserver exits during maintenance it is restarted once ExitMaintenanceMode is called.
+func (s *INDIServer) EnterMaintenanceMode() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maintenance == nil {
+		s.maintenance = make(chan struct{})
+		s.log.Info("entered maintenance mode")
+	}
+}
+
+// ExitMaintenanceMode resumes automatic restarts.
+func (s *INDIServer) ExitMaintenanceMode() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maintenance != nil {
+		close(s.maintenance)
+		s.maintenance = nil
+		s.log.Info("exited maintenance mode")
+	}
+}
+
+// restart launches a new indiserver on the FIFO and starts every previously started driver
+// on it.
+func (s *INDIServer) restart(ctx context.Context) (*process, error) {
+	// Launch without holding the lock, so StopServer can end supervision while a new server
+	// is starting up.
+	p, fifo, err := s.launch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ctx.Err() != nil {
+		s.discard(p, fifo)
+		return nil, ctx.Err()
+	}
+
+	err = s.fifo.Close()
+	if err != nil {
+		s.log.WithError(err).Warn("error in s.fifo.Close")
+	}
+
+	s.proc = p
+	s.fifo = fifo
+
+	s.log.WithField("drivers", len(s.started)).Info("restarted indiserver")
+
+	for i, d := range s.started {
+		err = s.writeCommand(d.startCommand())
+		if err != nil {
+			s.log.WithError(err).WithField("driver", d.Driver).Warn("error restarting driver")
+			continue
+		}
+
+		s.started[i].Started = time.Now()
+	}
+
+	return p, nil
+}
+
+// discard kills a launched indiserver that is no longer wanted.
+func (s *INDIServer) discard(p *process, fifo io.WriteCloser) {
+	err := fifo.Close()
+	if err != nil {
+		s.log.WithError(err).Warn("error in fifo.Close")
+	}
+
+	s.killProcess(p)
+}
@@ -0,0 +1,202 @@
+package indiserver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+var testRetryPolicy = RetryPolicy{
+	InitialDelay: time.Millisecond,
+	MaxDelay:     10 * time.Millisecond,
+	Multiplier:   2,
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSuperviseRestartsServer(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, Supervise(testRetryPolicy))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := cmder.last()
+
+	_, err = s.StartDriver("indi_asi_ccd", "CCD 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.StartDriver("indi_eqmod_telescope", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.StopDriver("indi_eqmod_telescope", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLine(t, first, `start indi_asi_ccd -n "CCD 1"`)
+	expectLine(t, first, `start indi_eqmod_telescope`)
+	expectLine(t, first, `stop indi_eqmod_telescope`)
+
+	started := s.RunningDrivers()[0].Started
+
+	first.exit(errors.New("signal: segmentation fault"))
+
+	waitFor(t, "restart", func() bool { return cmder.count() == 2 })
+
+	// Only the drivers still running are started again.
+	second := cmder.last()
+	expectLine(t, second, `start indi_asi_ccd -n "CCD 1"`)
+
+	if !s.RunningDrivers()[0].Started.After(started) {
+		t.Fatal("expected the restart to update the driver start time")
+	}
+
+	err = s.StopServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if cmder.count() != 2 {
+		t.Fatalf("expected no restart after StopServer, got %d commands", cmder.count())
+	}
+
+	expectRemoved(t, s.fifoPath)
+}
+
+func TestSuperviseGivesUp(t *testing.T) {
+	policy := testRetryPolicy
+	policy.MaxRetries = 2
+
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, Supervise(policy))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmder.set(func(c *fakeCommander) { c.startErr = errors.New("no such file") })
+	cmder.last().exit(errors.New("exit status 1"))
+
+	waitFor(t, "supervisor to give up", func() bool {
+		select {
+		case <-s.supervisorDone:
+			return true
+		default:
+			return false
+		}
+	})
+
+	if cmder.count() != 3 {
+		t.Fatalf("expected 2 restart attempts, got %d", cmder.count()-1)
+	}
+
+	expectRemoved(t, s.fifoPath)
+
+	if s.ExitError() == nil || s.ExitError().Error() != "exit status 1" {
+		t.Fatalf("got %v, expected the exit error", s.ExitError())
+	}
+
+	_, err = s.StartDriver("indi_asi_ccd", "")
+	if err != ErrNotRunning {
+		t.Fatalf("got %v, expected ErrNotRunning", err)
+	}
+
+	cmder.set(func(c *fakeCommander) { c.startErr = nil })
+
+	err = s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cmder.count() != 4 {
+		t.Fatalf("expected a new server, got %d commands", cmder.count())
+	}
+
+	err = s.StopServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSuperviseWithoutMaxDelay(t *testing.T) {
+	policy := RetryPolicy{InitialDelay: time.Millisecond, Multiplier: 1, MaxRetries: 2}
+
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, Supervise(policy))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	// Every restarted server crashes right away.
+	for i := 1; i <= 3; i++ {
+		waitFor(t, "server", func() bool { return cmder.count() == i })
+		cmder.last().exit(errors.New("exit status 1"))
+	}
+
+	expectDone(t, s)
+
+	time.Sleep(20 * time.Millisecond)
+
+	if cmder.count() != 3 {
+		t.Fatalf("expected 2 restarts, got %d", cmder.count()-1)
+	}
+}
+
+func TestMaintenanceModeSuspendsRestarts(t *testing.T) {
+	cmder := &fakeCommander{}
+	s := newTestServer(t, afero.NewOsFs(), cmder, Supervise(testRetryPolicy))
+
+	err := s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopServer()
+
+	s.EnterMaintenanceMode()
+
+	if !s.Status().Maintenance {
+		t.Fatal("expected Status to report maintenance mode")
+	}
+
+	cmder.last().exit(errors.New("exit status 1"))
+
+	time.Sleep(30 * time.Millisecond)
+
+	if cmder.count() != 1 {
+		t.Fatalf("expected no restart during maintenance, got %d commands", cmder.count())
+	}
+
+	s.ExitMaintenanceMode()
+
+	waitFor(t, "restart", func() bool { return cmder.count() == 2 })
+
+	if s.Status().Maintenance {
+		t.Fatal("expected maintenance mode to be over")
+	}
+}
@@ -0,0 +1,54 @@
+package indiserver
+
+import (
+	"net"
+	"os"
+)
+
+// SystemdNotify makes the INDIServer report to systemd through sd_notify(3): READY=1 once
+// StartServer has started indiserver and STOPPING=1 when StopServer begins stopping it. Use
+// it to wrap indiserver in a unit with Type=notify. Nothing is sent unless NOTIFY_SOCKET is
+// set, so it is safe to use outside systemd.
+//
+// Socket activation isn't supported: indiserver binds its own port and can't take over an
+// inherited socket.
+func SystemdNotify() Option {
+	return func(s *INDIServer) {
+		s.sdNotify = true
+	}
+}
+
+// notify sends state to systemd if SystemdNotify is set.
+func (s *INDIServer) notify(state string) {
+	if !s.sdNotify {
+		return
+	}
+
+	err := sdNotify(state)
+	if err != nil {
+		s.log.WithError(err).Warn("error in sdNotify")
+	}
+}
+
+// sdNotify sends state to the socket in NOTIFY_SOCKET, if it is set.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if len(socket) == 0 {
+		return nil
+	}
+
+	// A leading @ is an abstract socket.
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+
+	return err
+}
@@ -0,0 +1,71 @@
+package indiserver
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestSystemdNotify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "indiserver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := path.Join(dir, "notify")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	old, ok := os.LookupEnv("NOTIFY_SOCKET")
+	os.Setenv("NOTIFY_SOCKET", socket)
+	defer func() {
+		if ok {
+			os.Setenv("NOTIFY_SOCKET", old)
+		} else {
+			os.Unsetenv("NOTIFY_SOCKET")
+		}
+	}()
+
+	s := newTestServer(t, afero.NewOsFs(), &fakeCommander{}, SystemdNotify(), StopTimeout(0))
+
+	err = s.StartServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectNotify(t, conn, "READY=1")
+
+	err = s.StopServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectNotify(t, conn, "STOPPING=1")
+}
+
+func expectNotify(t *testing.T, conn *net.UnixConn, expected string) {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	buf := make([]byte, 64)
+
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(buf[:n]) != expected {
+		t.Fatalf("got %q, expected %q", buf[:n], expected)
+	}
+}